@@ -0,0 +1,165 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/mitchellh/go-homedir"
+)
+
+// アーカイブの形式 ( ファイルの拡張子で判別する )
+const (
+	extZip   = ".zip"
+	extTar   = ".tar"
+	extTarGz = ".tar.gz"
+	extTgz   = ".tgz"
+)
+
+// アーカイブファイルをディスクに展開せずにメモリ上のファイルシステムとして開く
+//
+// prefix が指定されている場合は、アーカイブ内のそのディレクトリをルートとするファイルシステムを返し、
+// prefix 配下以外のファイルは読み込まない
+func Open(file string, prefix string) (billy.Filesystem, error) {
+	file, err := homedir.Expand(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand path: %w", err)
+	}
+
+	prefix = clean(prefix)
+
+	mfs := memfs.New()
+	switch name := strings.ToLower(file); {
+	case strings.HasSuffix(name, extZip):
+		err = readZip(mfs, file, prefix)
+	case strings.HasSuffix(name, extTarGz), strings.HasSuffix(name, extTgz):
+		err = readTar(mfs, file, prefix, true)
+	case strings.HasSuffix(name, extTar):
+		err = readTar(mfs, file, prefix, false)
+	default:
+		return nil, fmt.Errorf("unsupported archive: %s", file)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if prefix == "" {
+		return mfs, nil
+	}
+
+	info, err := mfs.Stat(prefix)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("prefix is not found in archive: %s", prefix)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat prefix: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("prefix is not a directory: %s", prefix)
+	}
+
+	chroot, err := mfs.Chroot(prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to chroot: %w", err)
+	}
+
+	return chroot, nil
+}
+
+func readZip(mfs billy.Filesystem, file string, prefix string) error {
+	reader, err := zip.OpenReader(file)
+	if err != nil {
+		return fmt.Errorf("failed to open zip: %w", err)
+	}
+	defer reader.Close()
+
+	for _, f := range reader.File {
+		name := clean(f.Name)
+		if f.FileInfo().IsDir() || !contains(prefix, name) {
+			continue
+		}
+
+		r, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open zip entry [%s]: %w", f.Name, err)
+		}
+		err = write(mfs, name, r)
+		r.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read zip entry [%s]: %w", f.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func readTar(mfs billy.Filesystem, file string, prefix string, gzipped bool) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return fmt.Errorf("failed to open tar: %w", err)
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if gzipped {
+		gr, err := gzip.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("failed to open gzip: %w", err)
+		}
+		defer gr.Close()
+		r = gr
+	}
+
+	reader := tar.NewReader(r)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read tar: %w", err)
+		}
+
+		// シンボリックリンクなどの通常のファイル以外は対象外とする
+		name := clean(header.Name)
+		if header.Typeflag != tar.TypeReg || !contains(prefix, name) {
+			continue
+		}
+
+		if err := write(mfs, name, reader); err != nil {
+			return fmt.Errorf("failed to read tar entry [%s]: %w", header.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func write(mfs billy.Filesystem, name string, r io.Reader) error {
+	f, err := mfs.Create(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(f, r)
+	return err
+}
+
+// prefix 配下のパスかどうか
+func contains(prefix string, name string) bool {
+	return prefix == "" || name == prefix || strings.HasPrefix(name, prefix+"/")
+}
+
+// パスをアーカイブ内のパス ( 先頭の / や . を含まず、アーカイブ外を指さない ) に変換する
+func clean(name string) string {
+	name = path.Clean("/" + strings.ReplaceAll(name, "\\", "/"))
+	return strings.TrimPrefix(name, "/")
+}
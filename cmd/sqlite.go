@@ -14,4 +14,6 @@ var SqliteCmd = &cobra.Command{
 func init() {
 	RootCmd.AddCommand(SqliteCmd)
 	SqliteCmd.AddCommand(sqlite.Gen.Cmd)
+	SqliteCmd.AddCommand(sqlite.Info.Cmd)
+	SqliteCmd.AddCommand(sqlite.FK.Cmd)
 }
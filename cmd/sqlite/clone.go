@@ -3,40 +3,136 @@ package sqlite
 import (
 	"fmt"
 
-	"github.com/go-git/go-billy/v5"
 	"github.com/go-git/go-git/v5/plumbing/transport/http"
-	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
 	"github.com/tys-muta/go-sqx/cmd/sqlite/config"
 	"github.com/tys-muta/go-sqx/git"
 	git_option "github.com/tys-muta/go-sqx/git/option"
 )
 
-func clone(repo string) (billy.Filesystem, error) {
-	cfg := config.Get().Remote
+// ホスト鍵の検証方法 ( 未指定の場合は known_hosts で検証する )
+const (
+	hostKeyCheckingStrict   = "strict"
+	hostKeyCheckingInsecure = "insecure"
+)
+
+// 認証情報の取得元 ( 未指定の場合はこの順番で探す )
+//
+// helper は公開リポジトリでも git credential fill を実行してしまうため、providers に指定した場合のみ利用する
+var defaultCredentialProviders = []string{"config", "env", "file", "netrc"}
+
+// 環境変数の認証情報の変数名 ( 未指定の場合 )
+const (
+	defaultUsernameEnv = "SQX_GIT_USERNAME"
+	defaultPasswordEnv = "SQX_GIT_PASSWORD"
+)
+
+// リポジトリをクローンする
+//
+// キャッシュを利用する場合はキャッシュディレクトリのリポジトリに差分のみをフェッチし、
+// 利用しない場合は一時ディレクトリにクローンする ( 一時ディレクトリは Snapshot.Close で削除する )
+func clone(cfg config.Remote, paths []string, useCache bool) (*git.Snapshot, error) {
+	repo := cfg.Repo
+
+	switch cfg.SSH.HostKeyChecking {
+	case "", hostKeyCheckingStrict, hostKeyCheckingInsecure:
+	default:
+		return nil, fmt.Errorf("unsupported host key checking: %s", cfg.SSH.HostKeyChecking)
+	}
 
 	options := []git_option.CloneOption{}
-	if v := cfg.PrivateKey; v.FilePath != "" {
-		// TODO: 合っているはずの秘密鍵でも key mismatch になってしまうため要調査
-		pKey, err := ssh.NewPublicKeysFromFile("user", v.FilePath, v.Password)
+	if git.IsSSH(repo) && (cfg.PrivateKey.FilePath != "" || cfg.SSH.Agent) {
+		auth, err := git.NewSSHAuth(repo, git.SSHConfig{
+			User:                  cfg.SSH.User,
+			PrivateKeyFile:        cfg.PrivateKey.FilePath,
+			PrivateKeyPassword:    cfg.PrivateKey.Password,
+			Agent:                 cfg.SSH.Agent,
+			KnownHosts:            cfg.SSH.KnownHosts,
+			InsecureIgnoreHostKey: cfg.SSH.HostKeyChecking == hostKeyCheckingInsecure,
+		})
 		if err != nil {
-			return nil, fmt.Errorf("failed to open public key: %w", err)
+			return nil, fmt.Errorf("failed to setup ssh auth: %w", err)
 		}
-		options = append(options, git_option.WithPublicKeys(*pKey))
+		options = append(options, git_option.WithAuth(auth))
 	}
-	if v := cfg.BasicAuth; v.Username != "" {
-		options = append(options, git_option.WithBasicAuth(http.BasicAuth{
-			Username: v.Username,
-			Password: v.Password,
-		}))
+	if git.IsHTTP(repo) {
+		providers, err := credentialProviders(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to setup credential providers: %w", err)
+		}
+		credential, err := git.ResolveCredential(repo, providers...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve credential: %w", err)
+		}
+		if credential != nil {
+			options = append(options, git_option.WithBasicAuth(http.BasicAuth{
+				Username: credential.Username,
+				Password: credential.Password,
+			}))
+		}
 	}
 	if v := cfg.Refs; v != "" {
 		options = append(options, git_option.WithReference(v))
 	}
+	if v := cfg.Depth; v > 0 {
+		options = append(options, git_option.WithDepth(v))
+	}
+	if cfg.SingleBranch {
+		options = append(options, git_option.WithSingleBranch())
+	}
+
+	// 表ファイルが存在するパス以外のツリーは解決しない
+	options = append(options, git_option.WithPaths(paths...))
+
+	if useCache {
+		snapshot, err := git.CloneCache(repo, cfg.CacheDir, options...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to clone with cache: %w", err)
+		}
+		return snapshot, nil
+	}
 
-	bfs, err := git.Clone(repo, options...)
+	snapshot, err := git.Clone(repo, options...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to clone: %w", err)
 	}
 
-	return bfs, nil
+	return snapshot, nil
+}
+
+// 設定された順番で認証情報の取得元を生成する
+func credentialProviders(cfg config.Remote) ([]git.CredentialProvider, error) {
+
+	names := cfg.Credential.Providers
+	if len(names) == 0 {
+		names = defaultCredentialProviders
+	}
+
+	usernameEnv := cfg.Credential.UsernameEnv
+	if usernameEnv == "" {
+		usernameEnv = defaultUsernameEnv
+	}
+	passwordEnv := cfg.Credential.PasswordEnv
+	if passwordEnv == "" {
+		passwordEnv = defaultPasswordEnv
+	}
+
+	providers := []git.CredentialProvider{}
+	for _, name := range names {
+		switch name {
+		case "config":
+			providers = append(providers, git.StaticCredential(cfg.BasicAuth.Username, cfg.BasicAuth.Password))
+		case "env":
+			providers = append(providers, git.EnvCredential(usernameEnv, passwordEnv))
+		case "file":
+			providers = append(providers, git.FileCredential(cfg.Credential.TokenFile, cfg.BasicAuth.Username))
+		case "netrc":
+			providers = append(providers, git.NetrcCredential(cfg.Credential.NetrcFile))
+		case "helper":
+			providers = append(providers, git.HelperCredential())
+		default:
+			return nil, fmt.Errorf("unsupported credential provider: %s", name)
+		}
+	}
+
+	return providers, nil
 }
@@ -0,0 +1,263 @@
+package sqlite
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tys-muta/go-sqx/cmd/sqlite/config"
+	"github.com/tys-muta/go-sqx/cmd/sqlite/types"
+)
+
+// 表ファイルのヘッダーとレコードが別のファイルに分かれているかどうか
+func isSeparateBody() bool {
+	head := config.Get().Head
+	body := config.Get().Body
+	return head.Path != body.Path || head.Ext != body.Ext
+}
+
+// レコードの表ファイルのカラム名が定義されている行数
+func bodyColumnNameRow() int {
+	if v := config.Get().Body.ColumnNameRow; v > 0 {
+		return v
+	}
+	return config.Get().Head.ColumnNameRow
+}
+
+// 表ファイルのヘッダー行の構成
+const (
+	headerDefault = ""
+	headerName    = "name"
+	headerNone    = "none"
+)
+
+// 表ファイルの行の構成 ( 0 の場合は行が存在しない )
+type layout struct {
+	NameRow    int
+	TypeRow    int
+	CommentRow int
+	StartRow   int
+}
+
+// カラムの定義を読み込む表ファイルの行の構成
+func headLayout(table types.Table) (layout, error) {
+	switch table.Header {
+	case headerDefault:
+		return layout{
+			NameRow:    config.Get().Head.ColumnNameRow,
+			TypeRow:    config.Get().Head.ColumnTypeRow,
+			CommentRow: config.Get().Head.ColumnCommentRow,
+			StartRow:   config.Get().Body.StartRow,
+		}, nil
+	case headerName:
+		return layout{NameRow: 1, StartRow: 2}, nil
+	case headerNone:
+		if len(table.Columns) == 0 {
+			return layout{}, fmt.Errorf("columns must be declared when header is none")
+		}
+		return layout{StartRow: 1}, nil
+	default:
+		return layout{}, fmt.Errorf("unsupported header: %s", table.Header)
+	}
+}
+
+// レコードを読み込む表ファイルの行の構成
+func bodyLayout(table types.Table) (layout, error) {
+	if table.Header != headerDefault {
+		return headLayout(table)
+	}
+	return layout{
+		NameRow:  bodyColumnNameRow(),
+		StartRow: config.Get().Body.StartRow,
+	}, nil
+}
+
+// 表ファイルのヘッダー行からカラムの定義を生成する
+//
+// カラムが宣言されている場合は宣言を定義とし、ヘッダー行が存在する場合は宣言と一致するかを検証する
+func tableColumns(table types.Table, layout layout) ([]types.Column, error) {
+	var nameRow, typeRow []string
+	if layout.NameRow > 0 && (len(table.Columns) == 0 || table.Length() >= layout.NameRow) {
+		v, err := table.Row(layout.NameRow)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get name row: %w", err)
+		}
+		nameRow = v
+	}
+	if layout.TypeRow > 0 && (len(table.Columns) == 0 || table.Length() >= layout.TypeRow) {
+		v, err := table.Row(layout.TypeRow)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get type row: %w", err)
+		}
+		typeRow = v
+	}
+	if typeRow != nil && len(nameRow) != len(typeRow) {
+		return nil, fmt.Errorf("mismatch length of columns. name: %d, type: %d", len(nameRow), len(typeRow))
+	}
+
+	// カラムの説明 ( 表示名など ) の行
+	commentMap := map[string]string{}
+	if layout.CommentRow > 0 && nameRow != nil {
+		commentRow, err := table.Row(layout.CommentRow)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get comment row: %w", err)
+		}
+		for i, name := range nameRow {
+			if i < len(commentRow) {
+				commentMap[columnName(name)] = commentRow[i]
+			}
+		}
+	}
+
+	if len(table.Columns) > 0 {
+		if err := validateColumns(table.Columns, nameRow, typeRow); err != nil {
+			return nil, err
+		}
+		// 宣言に説明が無い場合は表ファイルの説明を適用する
+		columns := []types.Column{}
+		for _, column := range table.Columns {
+			if column.Comment == "" {
+				column.Comment = commentMap[column.Name]
+			}
+			columns = append(columns, column)
+		}
+		return columns, nil
+	}
+
+	if err := checkColumnCollision(nameRow); err != nil {
+		return nil, err
+	}
+
+	columns := []types.Column{}
+	for i, name := range nameRow {
+		// 型が表ファイルに定義されていない場合 ( JSON や YAML など ) は設定ファイルの型を適用する
+		v := ""
+		if typeRow != nil {
+			v = typeRow[i]
+		}
+		if v == "" {
+			v = table.ColumnTypes[name]
+		}
+		columns = append(columns, types.Column{
+			Type:    types.ColumnType(v),
+			Name:    columnName(name),
+			Comment: commentMap[columnName(name)],
+		})
+	}
+
+	return columns, nil
+}
+
+// ヘッダー行のカラム名と型が宣言されたカラムと一致するかを検証する ( 型が空のセルは検証しない )
+func validateColumns(columns []types.Column, nameRow []string, typeRow []string) error {
+	columnMap := map[string]types.Column{}
+	for _, column := range columns {
+		columnMap[column.Name] = column
+	}
+
+	diffs := []string{}
+	for i, name := range nameRow {
+		column, ok := columnMap[columnName(name)]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("undeclared column: %s", name))
+			continue
+		}
+		if typeRow == nil || typeRow[i] == "" {
+			continue
+		}
+		if v := types.ColumnType(typeRow[i]); v != column.Type {
+			diffs = append(diffs, fmt.Sprintf("mismatch type of column: %s (declared: %s, header: %s)", name, column.Type, v))
+		}
+	}
+	if len(diffs) > 0 {
+		return fmt.Errorf("header does not match declared columns: %s", strings.Join(diffs, ", "))
+	}
+
+	return nil
+}
+
+// 宣言されたカラムを定義に変換する
+func declaredColumns(columns []config.Column) ([]types.Column, error) {
+	names := []string{}
+	for _, v := range columns {
+		names = append(names, v.Name)
+	}
+	if err := checkColumnCollision(names); err != nil {
+		return nil, err
+	}
+
+	declared := []types.Column{}
+	for _, v := range columns {
+		if !types.IsColumnType(v.Type) {
+			return nil, fmt.Errorf("unsupported column type: %s (column: %s)", v.Type, v.Name)
+		}
+		declared = append(declared, types.Column{
+			Type:     types.ColumnType(v.Type),
+			Name:     columnName(v.Name),
+			Nullable: v.Nullable,
+			Default:  v.Default,
+			Comment:  v.Comment,
+		})
+	}
+	return declared, nil
+}
+
+// ヘッダー行が無い表ファイルのレコードを宣言されたカラムの数に合わせる
+func padColumns(columns []types.Column, rows [][]string) ([][]string, error) {
+	padded := [][]string{}
+	for n, row := range rows {
+		if len(row) > len(columns) {
+			return nil, fmt.Errorf("too many columns. row: %d, columns: %d, declared: %d", n+1, len(row), len(columns))
+		}
+		v := make([]string, len(columns))
+		copy(v, row)
+		padded = append(padded, v)
+	}
+	return padded, nil
+}
+
+// 表ファイルのカラムをカラム名で定義のカラムに対応付けて並べ替える
+//
+// 表ファイルに存在しないカラムは空 ( 型ごとの既定値 ) とし、定義に存在しないカラムはエラーとする
+func mapColumnsByName(table types.Table, columns []types.Column, rows [][]string, nameRowNum int) ([][]string, error) {
+	nameRow, err := table.Row(nameRowNum)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get name row: %w", err)
+	}
+
+	columnMap := map[string]bool{}
+	for _, column := range columns {
+		columnMap[column.Name] = true
+	}
+
+	indexMap := map[string]int{}
+	unknowns := []string{}
+	for i, name := range nameRow {
+		name = columnName(name)
+		if !columnMap[name] {
+			unknowns = append(unknowns, name)
+			continue
+		}
+		if _, ok := indexMap[name]; ok {
+			return nil, fmt.Errorf("duplicate column: %s", name)
+		}
+		indexMap[name] = i
+	}
+	if len(unknowns) > 0 {
+		sort.Strings(unknowns)
+		return nil, fmt.Errorf("unknown columns: %s", strings.Join(unknowns, ", "))
+	}
+
+	mapped := [][]string{}
+	for _, row := range rows {
+		v := make([]string, len(columns))
+		for i, column := range columns {
+			if index, ok := indexMap[column.Name]; ok && index < len(row) {
+				v[i] = row[index]
+			}
+		}
+		mapped = append(mapped, v)
+	}
+
+	return mapped, nil
+}
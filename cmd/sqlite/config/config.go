@@ -1,6 +1,8 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -15,44 +17,145 @@ import (
 type Config struct {
 	Timezone string
 	Location time.Location
-	Local    struct {
-		Path string
-	}
-	Remote struct {
-		Repo       string
-		Refs       string
-		PrivateKey struct {
-			FilePath string
-			Password string
-		}
-		BasicAuth struct {
-			Username string
-			Password string
-		}
-	}
-	Head struct {
+	Local    Local
+	Archive  Archive
+	Remote   Remote
+	// 複数の保存先を重ね合わせる場合に指定 ( 指定した場合は Local, Archive, Remote より優先する )
+	Sources []Source
+	Head    struct {
 		Ext           string
 		Path          string
 		ColumnNameRow int
 		ColumnTypeRow int
+		// カラムの説明 ( 表示名など ) が定義されている行数 ( 0 の場合は読み込まない )
+		ColumnCommentRow int
 	}
 	Body struct {
 		Ext      string
 		Path     string
 		StartRow int
+		// ヘッダーとレコードが別のファイルの場合にカラム名が定義されている行数 ( 省略時は Head.ColumnNameRow )
+		ColumnNameRow int
+	}
+	// プロファイルごとのオーバーレイ ( Path 配下のプロファイル名のディレクトリ )
+	Overlay struct {
+		Ext          string
+		Path         string
+		DeleteColumn string
 	}
 	XLSX struct {
+		Sheet      string
+		MergedCell string
+	}
+	ODS struct {
 		Sheet string
 	}
-	Table map[string]Table
+	Markdown struct {
+		Table string
+	}
+	// テーブル名とカラム名の命名規則 ( pascal : 既定, camel, snake, asis ) と、変換後の名前に付ける接頭辞
+	Naming struct {
+		Table        string
+		Column       string
+		TablePrefix  string
+		ColumnPrefix string
+	}
+	// 全てのテーブルとカラムの型やキーなどの情報を _sqx_tables, _sqx_columns に書き込む
+	Introspection bool
+	// カラム名 ( <テーブル><プライマリーキー> e.g. standardId ) から推論した外部キー制約を適用する
+	InferForeignKeys bool
+	Table            map[string]Table
+}
+
+type Local struct {
+	Path string
+	Refs string
+}
+
+type Archive struct {
+	Path   string
+	Prefix string
+}
+
+type Remote struct {
+	Repo       string
+	Refs       string
+	PrivateKey struct {
+		FilePath string
+		Password string
+	}
+	SSH struct {
+		User            string
+		Agent           bool
+		KnownHosts      []string
+		HostKeyChecking string
+	}
+	BasicAuth struct {
+		Username string
+		Password string
+	}
+	Credential struct {
+		Providers   []string
+		UsernameEnv string
+		PasswordEnv string
+		TokenFile   string
+		NetrcFile   string
+	}
+	CacheDir     string
+	Depth        int
+	SingleBranch bool
+}
+
+// 重ね合わせる保存先 ( 後に指定したものほど優先される )
+type Source struct {
+	// ログやビルド情報に表示する名前
+	Name    string
+	Local   Local
+	Archive Archive
+	Remote  Remote
+	// 省略時は Head.Path, Body.Path を利用する
+	Head struct {
+		Path string
+	}
+	Body struct {
+		Path string
+	}
+	// 優先度の低い保存先で定義されているテーブルの上書きを許可する
+	Override bool
 }
 
 type Table struct {
-	PrimaryKey  []string
-	UniqueKeys  [][]string
-	IndexKeys   [][]string
-	ForeignKeys []types.ForeignKey
-	ShardTypes  []string
+	// 複数の設定キーに一致する場合の優先度 ( 大きいものを優先する )
+	Priority int
+	// テーブル名 ( 指定した場合は命名規則を適用せずにそのまま利用する )
+	Name string
+	// テーブルの説明
+	Description string
+	// 外部キー制約を推論しないカラム
+	IgnoreForeignKeys []string
+	PrimaryKey        []string
+	UniqueKeys        [][]string
+	IndexKeys         [][]string
+	ForeignKeys       []types.ForeignKey
+	ShardTypes        []string
+	ColumnTypes       map[string]string
+	// 分割されたテーブルのカラムを順番ではなくカラム名で対応付ける
+	MapColumnsByName bool
+	// 宣言されたカラム ( 指定した場合はヘッダー行の代わりに利用し、ヘッダー行が存在する場合は検証する )
+	Columns []Column
+	// 表ファイルのヘッダー行の構成 ( "" : head, body の設定どおり, name : 1 行目がカラム名, none : ヘッダー行無し )
+	Header string
+	// カラムを宣言したスキーマファイルの起点となるパスからのパス ( 省略時は .json, .jsonl, .yaml のみ表ファイルと同じディレクトリの <ファイル名>.schema.toml )
+	Schema string
+}
+
+type Column struct {
+	Name     string
+	Type     string
+	Nullable bool
+	// 値が空の場合に利用する値
+	Default string
+	Comment string
 }
 
 const (
@@ -61,6 +164,8 @@ const (
 
 var (
 	cfg Config
+	// 設定ファイルの内容の SHA-256 ハッシュ ( 設定ファイルが存在しない場合は空 )
+	hash string
 )
 
 func init() {
@@ -76,6 +181,9 @@ func init() {
 			log.Fatal(err)
 		} else if err := toml.Unmarshal(v, &cfg); err != nil {
 			log.Fatal(err)
+		} else {
+			sum := sha256.Sum256(v)
+			hash = hex.EncodeToString(sum[:])
 		}
 	}
 
@@ -94,3 +202,7 @@ func init() {
 func Get() Config {
 	return cfg
 }
+
+func Hash() string {
+	return hash
+}
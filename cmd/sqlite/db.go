@@ -5,11 +5,10 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"strconv"
+	"sort"
 	"strings"
 
 	"github.com/go-git/go-billy/v5"
-	"github.com/iancoleman/strcase"
 	"github.com/tys-muta/go-sqx/cmd/sqlite/config"
 	"github.com/tys-muta/go-sqx/cmd/sqlite/option"
 	"github.com/tys-muta/go-sqx/cmd/sqlite/query"
@@ -18,7 +17,14 @@ import (
 	"github.com/tys-muta/go-sqx/fs"
 )
 
-func createDB(bfs billy.Filesystem, dbFile string) error {
+// 表ファイルを読み込むファイルシステムと、取り込みの起点となるパス
+type root struct {
+	FS   billy.Filesystem
+	Path string
+}
+
+// overlay はプロファイルが指定されていない場合はファイルシステムを持たない
+func createDB(head root, body root, overlay root, dbFile string, meta *buildMeta) error {
 	log.Printf("🔽 Create database")
 	if err := os.RemoveAll(dbFile); err != nil {
 		return fmt.Errorf("failed to remove db file: %w", err)
@@ -35,96 +41,175 @@ func createDB(bfs billy.Filesystem, dbFile string) error {
 	}
 
 	log.Printf("🔽 Create tables")
-	argMap, err := createTables(db, bfs)
+	argMap, err := createTables(db, head, meta)
 	if err != nil {
 		return fmt.Errorf("failed to create: %w", err)
 	}
 
 	log.Printf("🔽 Insert records")
-	err = insertRecords(db, bfs, argMap)
+	err = insertRecords(db, body, overlay, argMap, meta)
 	if err != nil {
 		return fmt.Errorf("failed to insert: %w", err)
 	}
 
+	log.Printf("🔽 Write metadata")
+	if err := writeMeta(db, meta); err != nil {
+		return fmt.Errorf("failed to write metadata: %w", err)
+	}
+	if err := writeColumns(db, argMap); err != nil {
+		return fmt.Errorf("failed to write columns: %w", err)
+	}
+	if config.Get().Introspection {
+		if err := writeTables(db, argMap, meta); err != nil {
+			return fmt.Errorf("failed to write tables: %w", err)
+		}
+	}
+
 	return nil
 }
 
-func createTables(db *sql.DB, bfs billy.Filesystem) (map[string]types.Definition, error) {
+func createTables(db *sql.DB, root root, meta *buildMeta) (map[string]types.Definition, error) {
+	defMap, suggestions, err := defineTables(root, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	// 推論した外部キー制約を適用する
+	if config.Get().InferForeignKeys {
+		for _, v := range suggestions {
+			log.Printf("🔽 Infer foreign key [%s]", v)
+			if len(v.Candidates) > 0 {
+				continue
+			}
+			def := defMap[v.Table]
+			def.Options = append(def.Options, option.WithForeignKey(v.ForeignKey))
+			defMap[v.Table] = def
+		}
+	}
+
+	for _, def := range defMap {
+		query, err := query.Create(def.Name, def.Columns, def.Options...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate creation query: %w", err)
+		}
+
+		if _, err := db.Exec(query); err != nil {
+			return nil, fmt.Errorf("failed to execute creation query: %w", err)
+		}
+	}
+
+	return defMap, nil
+}
+
+// 表ファイルのヘッダーからテーブルの定義を生成し、命名規則から推論した外部キー制約を返す
+func defineTables(root root, meta *buildMeta) (map[string]types.Definition, []foreignKeySuggestion, error) {
 	defMap := map[string]types.Definition{}
 
 	head := config.Get().Head
 
-	tables, err := scanTables(bfs, head.Path, head.Ext)
+	tables, err := scanTables(root.FS, root.Path, head.Ext)
 	if err != nil {
-		return nil, fmt.Errorf("failed to scan: %w", err)
+		return nil, nil, fmt.Errorf("failed to scan: %w", err)
 	}
 
+	// 分割されたテーブルのヘッダーの差異
+	diffs := []string{}
+	canonicalMap := map[string]string{}
+	idMap := map[string]string{}
+	foreignKeyMap := map[string][]types.ForeignKey{}
+	baseMap := map[string]types.Table{}
 	for _, table := range tables {
-		def := types.Definition{}
+		meta.addSource(table)
 
-		nameRow, err := table.Row(head.ColumnNameRow)
+		layout, err := headLayout(table)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get name row[%s]: %w", table.Index, err)
+			return nil, nil, fmt.Errorf("failed to get layout[%s]: %w", table.Index, err)
 		}
 
-		typeRow, err := table.Row(head.ColumnTypeRow)
+		columns, err := tableColumns(table, layout)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get type row[%s]: %w", table.Index, err)
+			return nil, nil, fmt.Errorf("failed to get columns[%s]: %w", table.Index, err)
 		}
 
-		if len(nameRow) != len(typeRow) {
-			return nil, fmt.Errorf("mismatch length of columns. name: %d, type: %d", len(nameRow), len(typeRow))
+		// 異なるテーブルが命名規則の適用によって同じテーブル名となる場合はエラーとする
+		if id, ok := idMap[table.Name]; ok && id != table.ID {
+			return nil, nil, fmt.Errorf("table name collision: %s (%s, %s)", table.Name, id, table.ID)
 		}
+		idMap[table.Name] = table.ID
 
-		def.Options = append(def.Options, option.WithPrimaryKey(table.PrimaryKey))
-		def.Options = append(def.Options, option.WithUniqueKey(table.UniqueKeys...))
-		def.Options = append(def.Options, option.WithIndexKey(table.IndexKeys...))
-		def.Options = append(def.Options, option.WithForeignKey(table.ForeignKeys...))
-		def.Options = append(def.Options, option.WithShardColumn(table.ShardColumns...))
+		if def, ok := defMap[table.Name]; ok {
+			// 分割されているテーブルでは定義が複数発生しうるため、最初の表ファイルの定義を基準としてヘッダーが一致するかを検証する
+			for _, v := range diffColumns(def.Columns[len(table.ShardColumns):], columns, table.MapColumnsByName) {
+				diffs = append(diffs, fmt.Sprintf("%s (base: %s): %s", table.Index, canonicalMap[table.Name], v))
+			}
+			continue
+		}
 
-		def.Columns = append(def.Columns, table.ShardColumns...)
+		if err := checkShardCollision(table.ShardColumns, columns); err != nil {
+			return nil, nil, fmt.Errorf("failed to get columns[%s]: %w", table.Index, err)
+		}
 
-		if _, ok := defMap[table.Name]; ok {
-			// 分割されているテーブルでは定義が複数発生しうるため、定義が既に存在する場合はスキップする
-			continue
+		def := types.Definition{}
+		def.Options = append(def.Options, option.WithPrimaryKey(columnNames(table.PrimaryKey)))
+		for _, v := range table.UniqueKeys {
+			def.Options = append(def.Options, option.WithUniqueKey(columnNames(v)))
 		}
+		for _, v := range table.IndexKeys {
+			def.Options = append(def.Options, option.WithIndexKey(columnNames(v)))
+		}
+		def.Options = append(def.Options, option.WithShardColumn(table.ShardColumns...))
+		def.Options = append(def.Options, option.WithComment(table.Description))
 
 		def.Name = table.Name
-		for i, v := range typeRow {
-			def.Columns = append(def.Columns, types.Column{
-				Type: types.ColumnType(v),
-				Name: strcase.ToCamel(nameRow[i]),
-			})
-		}
+		def.Columns = append(def.Columns, table.ShardColumns...)
+		def.Columns = append(def.Columns, columns...)
 
 		defMap[table.Name] = def
-	}
-
-	for _, def := range defMap {
-		query, err := query.Create(def.Name, def.Columns, def.Options...)
-		if err != nil {
-			return nil, fmt.Errorf("failed to generate creation query: %w", err)
+		canonicalMap[table.Name] = table.Index
+		baseMap[table.Name] = table
+		if len(table.ForeignKeys) > 0 {
+			foreignKeyMap[table.Name] = table.ForeignKeys
 		}
+	}
+	if len(diffs) > 0 {
+		return nil, nil, fmt.Errorf("inconsistent headers of sharded tables:\n%s", strings.Join(diffs, "\n"))
+	}
 
-		if _, err := db.Exec(query); err != nil {
-			return nil, fmt.Errorf("failed to execute creation query: %w", err)
-		}
+	// 外部キー制約は全てのテーブルの定義が揃ってから参照先を解決する
+	tableMap := map[string]string{}
+	for name, id := range idMap {
+		tableMap[id] = name
+	}
+	if err := resolveForeignKeys(defMap, foreignKeyMap, tableMap); err != nil {
+		return nil, nil, err
 	}
 
-	return defMap, nil
+	return defMap, inferForeignKeys(defMap, baseMap), nil
 }
 
-func insertRecords(db *sql.DB, bfs billy.Filesystem, defMap map[string]types.Definition) error {
+func insertRecords(db *sql.DB, root root, overlay root, defMap map[string]types.Definition, meta *buildMeta) error {
 	body := config.Get().Body
 
-	tables, err := scanTables(bfs, body.Path, body.Ext)
+	tables, err := scanTables(root.FS, root.Path, body.Ext)
 	if err != nil {
 		return fmt.Errorf("failed to scan: %w", err)
 	}
 
+	overlayMap, err := scanOverlays(overlay, tables)
+	if err != nil {
+		return fmt.Errorf("failed to scan overlays: %w", err)
+	}
+
 	queries := []string{}
 	for _, table := range tables {
-		startRow := body.StartRow
+		meta.addSource(table)
+
+		layout, err := bodyLayout(table)
+		if err != nil {
+			return fmt.Errorf("failed to get layout[%s]: %w", table.Index, err)
+		}
+
+		startRow := layout.StartRow
 		if table.Length() < startRow {
 			return fmt.Errorf("not enough rows. rows: %d, start row: %d", table.Length(), startRow)
 		}
@@ -136,6 +221,28 @@ func insertRecords(db *sql.DB, bfs billy.Filesystem, defMap map[string]types.Def
 
 		rows := table.Rows[startRow-1:]
 
+		// ヘッダーとレコードが別のファイルの場合、カラム名で対応付ける場合、またはカラムが宣言されている場合は定義のカラムの順番に並べ替える
+		// ヘッダー行が無い場合は宣言されたカラムの順番とする
+		columns := def.Columns[len(table.ShardColumns):]
+		switch {
+		case layout.NameRow == 0:
+			if rows, err = padColumns(columns, rows); err != nil {
+				return fmt.Errorf("failed to map columns [%s]: %w", table.Path, err)
+			}
+		case isSeparateBody() || table.MapColumnsByName || len(table.Columns) > 0:
+			if rows, err = mapColumnsByName(table, columns, rows, layout.NameRow); err != nil {
+				return fmt.Errorf("failed to map columns [%s]: %w", table.Path, err)
+			}
+		}
+
+		// プロファイルのオーバーレイが存在する場合は元のレコードに適用する
+		if v, ok := overlayMap[strings.TrimPrefix(table.Index, "/")]; ok {
+			meta.addSource(v)
+			if rows, err = applyOverlay(table, columns, rows, v); err != nil {
+				return fmt.Errorf("failed to apply overlay [%s]: %w", v.Path, err)
+			}
+		}
+
 		// 分割されたテーブルの場合、分割キーを先頭に追加する
 		shardColumns := []string{}
 		for _, column := range table.ShardColumns {
@@ -200,99 +307,149 @@ func scanTables(bfs billy.Filesystem, path string, ext string) ([]types.Table, e
 		return nil, fmt.Errorf("failed to read: %w", err)
 	}
 
+	keys, err := compileTableKeys(config.Get().Table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile table keys: %w", err)
+	}
+
 	for index, file := range fileMap {
 		rows, err := table.Parse(bfs, file)
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse: %w", err)
 		}
 
+		hash, err := fs.Hash(bfs, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash: %w", err)
+		}
+
+		id := strings.TrimPrefix(index, "/")
 		table := types.Table{
 			Index: index,
-			Name:  strcase.ToCamel(strings.Replace(index, "/", "_", -1)),
+			ID:    id,
+			Name:  tableName(id),
+			Path:  file.Path,
+			Hash:  hash,
 			Rows:  rows,
 		}
 
-		// ファイルに対応する設定があれば適用する
-		for k, v := range config.Get().Table {
-			associate(&table, k, v)
+		// ファイルに対応する設定があれば、優先度の最も高い設定を適用する
+		for _, key := range keys {
+			if m, ok := key.match(table.Index); ok {
+				if err := associate(&table, m); err != nil {
+					return nil, fmt.Errorf("failed to associate [%s]: %w", file.Path, err)
+				}
+				break
+			}
+		}
+
+		// カラムが設定で宣言されていない場合はスキーマファイルの宣言を適用する
+		if err := loadSchema(bfs, path, file, &table); err != nil {
+			return nil, fmt.Errorf("failed to load schema [%s]: %w", file.Path, err)
 		}
 
 		tables = append(tables, table)
 	}
 
+	// 分割されたテーブルの基準となる表ファイルが一定となるように索引の順に並べる
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Index < tables[j].Index })
+
 	return tables, nil
 }
 
 // テーブルの索引キーに対応する設定があれば関連付ける
-func associate(table *types.Table, key string, cfg config.Table) {
-	// 設定キーから接頭辞とパラメータ名を抽出する
-	paramNameMap := map[int]string{}
-	paramNames := []string{}
-	paramIndexes := []int{}
-	keyIDs := []string{}
-	for i, v := range strings.Split(key, "/") {
-		if strings.HasPrefix(v, ":") {
-			paramNameMap[i] = strings.TrimPrefix(v, ":")
-			paramNames = append(paramNames, strings.TrimPrefix(v, ":"))
-			paramIndexes = append(paramIndexes, i)
-		} else {
-			keyIDs = append(keyIDs, v)
-		}
-	}
-	keyID := strings.Join(keyIDs, "/")
-
-	// テーブルの索引キーと設定の一意キーが完全一致しない場合はシャードテーブルか判定
-	if table.Index != keyID {
-		values := strings.Split(table.Index, "/")
-		tableIDs := []string{}
-		for i, value := range values {
-			if _, ok := paramNameMap[i]; !ok {
-				tableIDs = append(tableIDs, value)
-			}
-		}
-		tableID := strings.Join(tableIDs, "/")
-		if tableID != keyID {
-			// シャードテーブルではないので関連付けない
-			return
-		}
-
-		// シャードテーブルの場合はパラメータの値を取得する
-		j := 0
-		for k, paramName := range paramNames {
-			i := paramIndexes[k]
-			if len(values) <= i || len(cfg.ShardTypes) <= j {
-				return
-			}
-			paramValue := values[i]
-			shardType := cfg.ShardTypes[j]
-			column := types.Column{
-				Name:  strcase.ToCamel(paramName),
-				Type:  types.ColumnType(shardType),
-				Value: paramValue,
-			}
-			switch column.Type {
-			case types.ColumnTypeInteger:
-				// シャードキーの型が整数でない場合は関連付けない
-				_, err := strconv.Atoi(paramValue)
-				if err != nil {
-					return
-				}
-			case types.ColumnTypeString, types.ColumnTypeNullString:
-				// do nothing
-			default:
-				// シャードキーの型が整数でも文字列でもない場合は関連付けない
-				return
-			}
+// 設定キーに一致したテーブルに設定を適用する ( 分割キーの値が型に合わない場合はエラーとする )
+func associate(table *types.Table, m *tableMatch) error {
+	cfg := m.key.cfg
 
-			table.ShardColumns = append(table.ShardColumns, column)
+	if len(m.params) != len(cfg.ShardTypes) {
+		return fmt.Errorf("mismatch number of shard types. key: %s, params: %d, shard types: %d", m.key.key, len(m.params), len(cfg.ShardTypes))
+	}
 
-			j++
+	shardColumns := []types.Column{}
+	for i, param := range m.params {
+		column, err := shardColumn(param[0], cfg.ShardTypes[i], param[1])
+		if err != nil {
+			return fmt.Errorf("failed to parse shard key. key: %s: %w", m.key.key, err)
 		}
+		shardColumns = append(shardColumns, column)
 	}
 
-	table.Name = strcase.ToCamel(strings.Replace(keyID, "/", "_", -1))
+	table.ID = m.id
+	table.Name = tableName(m.id)
+	if cfg.Name != "" {
+		table.Name = cfg.Name
+	}
+	table.ShardColumns = shardColumns
 	table.PrimaryKey = cfg.PrimaryKey
 	table.UniqueKeys = cfg.UniqueKeys
 	table.IndexKeys = cfg.IndexKeys
 	table.ForeignKeys = cfg.ForeignKeys
+	table.ColumnTypes = cfg.ColumnTypes
+	table.MapColumnsByName = cfg.MapColumnsByName
+	columns, err := declaredColumns(cfg.Columns)
+	if err != nil {
+		return err
+	}
+	table.Columns = columns
+	table.Header = cfg.Header
+	table.Description = cfg.Description
+	table.Key = m.key.key
+	table.IgnoreForeignKeys = cfg.IgnoreForeignKeys
+	table.Schema = cfg.Schema
+
+	return nil
+}
+
+// スキーマファイルで宣言されたカラムとヘッダー行の構成、カラムの型をテーブルに適用する
+//
+// スキーマファイルが設定で指定されている場合は起点となるパスから、
+// 指定されていない場合は型の行を持たない形式のみ表ファイルと同じディレクトリから読み込む
+func loadSchema(bfs billy.Filesystem, root string, file fs.File, t *types.Table) error {
+	path := ""
+	switch {
+	case t.Schema != "":
+		path = bfs.Join(root, t.Schema)
+	case table.HasSchemaFile(file):
+		path = table.SchemaPath(file)
+	default:
+		return nil
+	}
+
+	schema, err := table.ReadSchema(bfs, path)
+	if err != nil {
+		return err
+	}
+	if schema == nil && t.Schema != "" {
+		return fmt.Errorf("schema file is not found: %s", path)
+	}
+	if schema == nil {
+		return nil
+	}
+
+	// カラムが宣言されていない場合は、型が未定義のセルにスキーマの型を適用する
+	if len(schema.Columns) == 0 {
+		if t.Header == headerDefault {
+			table.ApplyColumnTypes(t.Rows, schema.ColumnTypes)
+		}
+		return nil
+	}
+	// 設定で宣言されたカラムを優先する
+	if len(t.Columns) > 0 {
+		return nil
+	}
+
+	columns, err := declaredColumns(schema.Columns)
+	if err != nil {
+		return err
+	}
+	t.Columns = columns
+	if t.Header == "" {
+		t.Header = schema.Header
+	}
+	if t.Description == "" {
+		t.Description = schema.Description
+	}
+
+	return nil
 }
@@ -0,0 +1,275 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tys-muta/go-sqx/cmd/sqlite/config"
+	"github.com/tys-muta/go-sqx/cmd/sqlite/option"
+	"github.com/tys-muta/go-sqx/cmd/sqlite/types"
+)
+
+// テーブルとカラムの情報を保存するテーブル
+//
+// _sqx_columns のカラム名が空の行はテーブルの説明を表す
+// _sqx_tables と、_sqx_columns の型やキーなどの情報は設定ファイルの introspection が有効な場合のみ書き込む
+const (
+	tablesTable  = "_sqx_tables"
+	columnsTable = "_sqx_columns"
+)
+
+// 外部キーの参照先
+type reference struct {
+	Table  string
+	Column string
+}
+
+// メタデータのテーブルのカラム
+type metaColumn struct {
+	Name       string
+	Definition string
+	// introspection が有効な場合のみ作成する
+	Introspection bool
+}
+
+// _sqx_columns のカラム
+var columnsTableColumns = []metaColumn{
+	{Name: "Table", Definition: "TEXT NOT NULL"},
+	{Name: "Column", Definition: "TEXT NOT NULL"},
+	{Name: "Comment", Definition: "TEXT NOT NULL"},
+	{Name: "Position", Definition: "INTEGER NOT NULL", Introspection: true},
+	{Name: "Type", Definition: "TEXT NOT NULL", Introspection: true},
+	{Name: "SQLType", Definition: "TEXT NOT NULL", Introspection: true},
+	{Name: "Nullable", Definition: "INTEGER NOT NULL", Introspection: true},
+	{Name: "Default", Definition: "TEXT NULL", Introspection: true},
+	{Name: "PrimaryKey", Definition: "INTEGER NOT NULL", Introspection: true},
+	{Name: "Unique", Definition: "INTEGER NOT NULL", Introspection: true},
+	{Name: "Indexed", Definition: "INTEGER NOT NULL", Introspection: true},
+	{Name: "Shard", Definition: "INTEGER NOT NULL", Introspection: true},
+	{Name: "Enum", Definition: "TEXT NULL", Introspection: true},
+	{Name: "ReferenceTable", Definition: "TEXT NULL", Introspection: true},
+	{Name: "ReferenceColumn", Definition: "TEXT NULL", Introspection: true},
+}
+
+// 生成したテーブルのカラムの説明 ( introspection が有効な場合は型やキーなどの情報も ) を書き込む
+func writeColumns(db *sql.DB, defMap map[string]types.Definition) error {
+	introspection := config.Get().Introspection
+
+	columns := []metaColumn{}
+	definitions := []string{}
+	for _, v := range columnsTableColumns {
+		if v.Introspection && !introspection {
+			continue
+		}
+		columns = append(columns, v)
+		definitions = append(definitions, fmt.Sprintf("`%s` %s", v.Name, v.Definition))
+	}
+	query := fmt.Sprintf("CREATE TABLE `%s` (%s, PRIMARY KEY (`Table`, `Column`))", columnsTable, strings.Join(definitions, ", "))
+	if _, err := db.Exec(query); err != nil {
+		return fmt.Errorf("failed to execute creation query: %w", err)
+	}
+
+	for _, name := range definitionNames(defMap) {
+		def := defMap[name]
+		o := createOptions(def)
+
+		// カラム名が空の行はテーブルの説明を表す
+		rows := []map[string]any{{
+			"Table":      name,
+			"Column":     "",
+			"Comment":    o.Comment,
+			"Position":   0,
+			"Type":       "",
+			"SQLType":    "",
+			"Nullable":   false,
+			"PrimaryKey": 0,
+			"Unique":     false,
+			"Indexed":    false,
+			"Shard":      false,
+		}}
+
+		references := foreignReferences(o.ForeignKeys)
+		for i, column := range def.Columns {
+			row := map[string]any{
+				"Table":      name,
+				"Column":     column.Name,
+				"Comment":    column.Comment,
+				"Position":   i + 1,
+				"Type":       column.Type.Name(),
+				"SQLType":    column.Type.SQLType(),
+				"Nullable":   column.IsNullable(),
+				"Default":    nullable(column.Default),
+				"PrimaryKey": primaryKeyPosition(def, o, column.Name),
+				"Unique":     containsKey(o.UniqueKeys, column.Name),
+				"Indexed":    containsKey(o.IndexKeys, column.Name),
+				"Shard":      i < len(o.ShardColumns),
+			}
+			if len(column.Enum) > 0 {
+				bytes, err := json.Marshal(column.Enum)
+				if err != nil {
+					return fmt.Errorf("failed to marshal enum: %w", err)
+				}
+				row["Enum"] = string(bytes)
+			}
+			if r, ok := references[column.Name]; ok {
+				row["ReferenceTable"], row["ReferenceColumn"] = r.Table, r.Column
+			}
+			rows = append(rows, row)
+		}
+
+		for _, row := range rows {
+			if err := insertMetaRow(db, columnsTable, columns, row); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// カラム名ごとの値を 1 行として書き込む ( 値が無いカラムは NULL とする )
+func insertMetaRow(db *sql.DB, table string, columns []metaColumn, row map[string]any) error {
+	names := []string{}
+	values := []any{}
+	for _, v := range columns {
+		names = append(names, fmt.Sprintf("`%s`", v.Name))
+		values = append(values, row[v.Name])
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ")
+	query := fmt.Sprintf("INSERT INTO `%s` (%s) VALUES (%s)", table, strings.Join(names, ", "), placeholders)
+	if _, err := db.Exec(query, values...); err != nil {
+		return fmt.Errorf("failed to execute insertion query: %w", err)
+	}
+
+	return nil
+}
+
+// 生成したテーブルのキーや分割キー、表ファイルの情報を書き込む
+func writeTables(db *sql.DB, defMap map[string]types.Definition, meta *buildMeta) error {
+	query := fmt.Sprintf("CREATE TABLE `%s` ("+
+		"`Table` TEXT NOT NULL, `Comment` TEXT NOT NULL, `PrimaryKey` TEXT NOT NULL, `UniqueKeys` TEXT NOT NULL, "+
+		"`IndexKeys` TEXT NOT NULL, `ForeignKeys` TEXT NOT NULL, `ShardColumns` TEXT NOT NULL, `Sources` TEXT NOT NULL, "+
+		"PRIMARY KEY (`Table`))", tablesTable)
+	if _, err := db.Exec(query); err != nil {
+		return fmt.Errorf("failed to execute creation query: %w", err)
+	}
+
+	for _, name := range definitionNames(defMap) {
+		def := defMap[name]
+		o := createOptions(def)
+
+		primaryKey := o.PrimaryKey
+		if len(primaryKey) == 0 {
+			// プライマリーキーの指定が無い場合は先頭カラムをプライマリキーにする
+			primaryKey = []string{def.Columns[0].Name}
+		}
+		shardColumns := []string{}
+		for _, column := range o.ShardColumns {
+			shardColumns = append(shardColumns, column.Name)
+		}
+		foreignKeys := []map[string]any{}
+		for _, v := range o.ForeignKeys {
+			foreignKeys = append(foreignKeys, map[string]any{
+				"columns":    v.Columns,
+				"table":      v.Table,
+				"references": v.References,
+				"onDelete":   v.OnDelete,
+				"onUpdate":   v.OnUpdate,
+				"deferrable": v.Deferrable,
+			})
+		}
+		sources := meta.Sources[name]
+		if sources == nil {
+			sources = []sourceFile{}
+		}
+
+		values := []any{name, o.Comment}
+		for _, v := range []any{primaryKey, nonNil(o.UniqueKeys), nonNil(o.IndexKeys), foreignKeys, shardColumns, sources} {
+			bytes, err := json.Marshal(v)
+			if err != nil {
+				return fmt.Errorf("failed to marshal: %w", err)
+			}
+			values = append(values, string(bytes))
+		}
+
+		query := fmt.Sprintf("INSERT INTO `%s` VALUES (?, ?, ?, ?, ?, ?, ?, ?)", tablesTable)
+		if _, err := db.Exec(query, values...); err != nil {
+			return fmt.Errorf("failed to execute insertion query: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func definitionNames(defMap map[string]types.Definition) []string {
+	names := []string{}
+	for name := range defMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func createOptions(def types.Definition) option.CreateOptions {
+	o := option.CreateOptions{}
+	for _, v := range def.Options {
+		v(&o)
+	}
+	return o
+}
+
+// プライマリーキー内でのカラムの位置 ( 1 始まり、含まれない場合は 0 )
+func primaryKeyPosition(def types.Definition, o option.CreateOptions, name string) int {
+	if len(o.PrimaryKey) == 0 {
+		if def.Columns[0].Name == name {
+			return 1
+		}
+		return 0
+	}
+	for i, v := range o.PrimaryKey {
+		if strings.EqualFold(v, name) {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+func containsKey(keys [][]string, name string) bool {
+	for _, key := range keys {
+		for _, v := range key {
+			if strings.EqualFold(v, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// 外部キー制約のカラムごとの参照先
+func foreignReferences(foreignKeys []types.ForeignKey) map[string]reference {
+	references := map[string]reference{}
+	for _, v := range foreignKeys {
+		for i, column := range v.Columns {
+			references[column] = reference{Table: v.Table, Column: v.References[i]}
+		}
+	}
+	return references
+}
+
+func nullable(v string) any {
+	if v == "" {
+		return nil
+	}
+	return v
+}
+
+func nonNil(v [][]string) [][]string {
+	if v == nil {
+		return [][]string{}
+	}
+	return v
+}
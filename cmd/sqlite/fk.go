@@ -0,0 +1,62 @@
+package sqlite
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/tys-muta/go-sqx/cmd/sqlite/config"
+)
+
+type f struct {
+	Cmd     *cobra.Command
+	Suggest *cobra.Command
+	NoCache bool
+}
+
+var FK = &f{
+	Cmd: &cobra.Command{
+		Use:   "fk",
+		Short: "Manage foreign keys",
+	},
+	Suggest: &cobra.Command{
+		Use:   "suggest",
+		Short: "Print foreign keys inferred from column names",
+		Long: `Prints foreign keys inferred from column names matching primary keys of other tables
+(e.g. standardId -> standard.id) in the format of sqlite_gen.toml for review`,
+	},
+}
+
+func init() {
+	FK.Suggest.RunE = FK.RunSuggest
+	FK.Suggest.Flags().BoolVarP(&FK.NoCache, "no-cache", "", false, "clone remote repository into temporary directory without using cache directory.")
+	FK.Cmd.AddCommand(FK.Suggest)
+}
+
+func (c *f) RunSuggest(command *cobra.Command, args []string) error {
+	if err := checkNaming(); err != nil {
+		return err
+	}
+
+	meta := &buildMeta{}
+	resources := closers{}
+	defer resources.Close()
+	head, _, _, err := openRoots(config.Get(), "", !c.NoCache, meta, &resources)
+	if err != nil {
+		return fmt.Errorf("filed to setup file system: %w", err)
+	}
+
+	_, suggestions, err := defineTables(head, meta)
+	if err != nil {
+		return fmt.Errorf("failed to define tables: %w", err)
+	}
+
+	for _, v := range suggestions {
+		if len(v.Candidates) > 0 {
+			fmt.Printf("# %s\n\n", v)
+			continue
+		}
+		fmt.Printf("%s\n\n", v.TOML())
+	}
+
+	return nil
+}
@@ -0,0 +1,198 @@
+package sqlite
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tys-muta/go-sqx/cmd/sqlite/option"
+	"github.com/tys-muta/go-sqx/cmd/sqlite/types"
+)
+
+// 外部キー制約の参照先の変更・削除時の動作
+var foreignKeyActions = []string{"CASCADE", "SET NULL", "SET DEFAULT", "RESTRICT", "NO ACTION"}
+
+// 旧形式 ( column, reference ) の外部キー制約を構造化した形式に変換する
+func parseForeignKey(fk types.ForeignKey) (types.ForeignKey, error) {
+	if fk.Reference == "" && fk.Column == "" {
+		return fk, nil
+	}
+	if len(fk.Columns) > 0 || fk.Table != "" || len(fk.References) > 0 {
+		return fk, fmt.Errorf("column and reference can not be used with columns, table and references")
+	}
+
+	open := strings.Index(fk.Reference, "(")
+	if open < 0 || !strings.HasSuffix(fk.Reference, ")") {
+		return fk, fmt.Errorf("invalid reference: %s", fk.Reference)
+	}
+	fk.Table = trimIdentifier(fk.Reference[:open])
+	for _, v := range strings.Split(fk.Reference[open+1:len(fk.Reference)-1], ",") {
+		fk.References = append(fk.References, trimIdentifier(v))
+	}
+	for _, v := range strings.Split(fk.Column, ",") {
+		fk.Columns = append(fk.Columns, trimIdentifier(v))
+	}
+	fk.Column, fk.Reference = "", ""
+
+	return fk, nil
+}
+
+func trimIdentifier(v string) string {
+	return strings.Trim(strings.TrimSpace(v), "`\"")
+}
+
+// 外部キー制約のテーブル名とカラム名を命名規則を適用した名前に解決し、参照先が存在して一意となるかを検証する
+//
+// idMap は表ファイルの索引 ( 分割キーを除く ) からテーブル名への対応
+func resolveForeignKey(def types.Definition, fk types.ForeignKey, defMap map[string]types.Definition, idMap map[string]string) (types.ForeignKey, error) {
+	fk, err := parseForeignKey(fk)
+	if err != nil {
+		return fk, err
+	}
+	if len(fk.Columns) == 0 || fk.Table == "" {
+		return fk, fmt.Errorf("columns and table are required")
+	}
+
+	resolved := types.ForeignKey{Deferrable: fk.Deferrable}
+
+	// 参照先は索引 ( e.g. shard/int ) とテーブル名のいずれでも記述できる
+	name, ok := idMap[strings.TrimPrefix(fk.Table, "/")]
+	if !ok {
+		name = fk.Table
+	}
+	target, ok := defMap[name]
+	if !ok {
+		return fk, fmt.Errorf("referenced table is not found: %s", fk.Table)
+	}
+	resolved.Table = target.Name
+
+	// 命名規則を適用した名前の他に、生成されるカラム名そのままの記述も受け付ける
+	for _, v := range fk.Columns {
+		column := columnName(v)
+		if !hasColumn(def, column) && hasColumn(def, v) {
+			column = v
+		}
+		if !hasColumn(def, column) {
+			return fk, fmt.Errorf("column is not found: %s", v)
+		}
+		resolved.Columns = append(resolved.Columns, column)
+	}
+
+	targetOptions := createOptions(target)
+	if len(fk.References) == 0 {
+		resolved.References = primaryKeyColumns(target, targetOptions)
+	}
+	for _, v := range fk.References {
+		column := columnName(v)
+		if !hasColumn(target, column) && hasColumn(target, v) {
+			column = v
+		}
+		if !hasColumn(target, column) {
+			return fk, fmt.Errorf("referenced column is not found: %s.%s", fk.Table, v)
+		}
+		resolved.References = append(resolved.References, column)
+	}
+	if len(resolved.Columns) != len(resolved.References) {
+		return fk, fmt.Errorf("mismatch number of columns. columns: %d, references: %d", len(resolved.Columns), len(resolved.References))
+	}
+
+	// 参照先のカラムはプライマリーキーまたはユニークキーと一致する必要がある
+	unique := isSameKey(resolved.References, primaryKeyColumns(target, targetOptions))
+	for _, v := range targetOptions.UniqueKeys {
+		unique = unique || isSameKey(resolved.References, v)
+	}
+	if !unique {
+		return fk, fmt.Errorf("referenced columns are not unique: %s(%s)", resolved.Table, strings.Join(resolved.References, ", "))
+	}
+
+	if resolved.OnDelete, err = foreignKeyAction(fk.OnDelete); err != nil {
+		return fk, fmt.Errorf("invalid on delete: %w", err)
+	}
+	if resolved.OnUpdate, err = foreignKeyAction(fk.OnUpdate); err != nil {
+		return fk, fmt.Errorf("invalid on update: %w", err)
+	}
+
+	return resolved, nil
+}
+
+// テーブルの外部キー制約を解決して定義に追加する ( 解決できない制約はまとめてエラーとする )
+func resolveForeignKeys(defMap map[string]types.Definition, foreignKeyMap map[string][]types.ForeignKey, idMap map[string]string) error {
+	names := []string{}
+	for name := range foreignKeyMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	errs := []string{}
+	resolvedMap := map[string][]types.ForeignKey{}
+	for _, name := range names {
+		for i, fk := range foreignKeyMap[name] {
+			resolved, err := resolveForeignKey(defMap[name], fk, defMap, idMap)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s.foreignKeys[%d]: %s", name, i, err))
+				continue
+			}
+			resolvedMap[name] = append(resolvedMap[name], resolved)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid foreign keys:\n%s", strings.Join(errs, "\n"))
+	}
+
+	for name, foreignKeys := range resolvedMap {
+		def := defMap[name]
+		def.Options = append(def.Options, option.WithForeignKey(foreignKeys...))
+		defMap[name] = def
+	}
+
+	return nil
+}
+
+func foreignKeyAction(v string) (string, error) {
+	if v == "" {
+		return "", nil
+	}
+	action := strings.ToUpper(strings.Join(strings.Fields(strings.Replace(v, "_", " ", -1)), " "))
+	for _, w := range foreignKeyActions {
+		if action == w {
+			return action, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported action: %s", v)
+}
+
+func hasColumn(def types.Definition, name string) bool {
+	for _, column := range def.Columns {
+		if column.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// プライマリーキーを構成するカラム ( 指定が無い場合は先頭カラム )
+func primaryKeyColumns(def types.Definition, o option.CreateOptions) []string {
+	if len(o.PrimaryKey) == 0 {
+		return []string{def.Columns[0].Name}
+	}
+	return o.PrimaryKey
+}
+
+// カラムの順番によらずに同じカラムで構成されているかを判定する
+func isSameKey(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, v := range a {
+		found := false
+		for _, w := range b {
+			if v == w {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
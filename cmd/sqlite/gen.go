@@ -2,25 +2,27 @@ package sqlite
 
 import (
 	"fmt"
-	"log"
 
-	"github.com/go-git/go-billy/v5"
-	"github.com/go-git/go-billy/v5/osfs"
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/spf13/cobra"
 	"github.com/tys-muta/go-sqx/cmd/sqlite/config"
 )
 
 type g struct {
-	Cmd *cobra.Command
-	Cfg config.Config
+	Cmd     *cobra.Command
+	Cfg     config.Config
+	NoCache bool
+	// 適用するオーバーレイのプロファイル名 ( e.g. dev, staging, production )
+	Profile string
+	// 表ファイルの保存先の解放処理
+	closers closers
 }
 
 var Gen = &g{
 	Cmd: &cobra.Command{
 		Use:   "gen",
 		Short: "Output SQLite database file",
-		Long: `Reads table data (.xlsx, .tsv, .csv ) from git repository
+		Long: `Reads table data (.xlsx, .tsv, .csv ) from git repository or archive
 and output SQLite database file`,
 	},
 }
@@ -30,10 +32,14 @@ func init() {
 	Gen.Cfg = config.Get()
 
 	// Gen.Cmd.Flags().StringVarP(&c.Cfg.Clone.Repo, "repo", "", c.Cfg.Clone.Repo, "git repository.")
+	Gen.Cmd.Flags().BoolVarP(&Gen.NoCache, "no-cache", "", false, "clone remote repository into temporary directory without using cache directory.")
+	Gen.Cmd.Flags().StringVarP(&Gen.Profile, "profile", "", "", "profile name of overlay to apply.")
 
 	// 以下の情報はコマンドラインで渡すのはセキュアではないため、フラグは用意しない
 	// - SSH プライベートキーのパスワード
 	// - Basic 認証のユーザーとパスワード
+	//
+	// Basic 認証の情報は設定ファイル以外に、環境変数、トークンファイル、.netrc、git credential helper ( providers に指定した場合のみ ) から取得できる
 }
 
 func (c *g) Run(command *cobra.Command, args []string) (retErr error) {
@@ -47,26 +53,28 @@ func (c *g) Run(command *cobra.Command, args []string) (retErr error) {
 		}
 	}()
 
-	// 元となるデータの保存先によってファイルシステムを切り替える
-	var bfs billy.Filesystem
-	var err error
-	switch {
-	case c.Cfg.Local.Path != "":
-		bfs = osfs.New(c.Cfg.Local.Path)
-		log.Printf("🔽 Local [path: %s]", c.Cfg.Local.Path)
-	case c.Cfg.Remote.Repo != "":
-		bfs, err = clone(c.Cfg.Remote.Repo)
-		log.Printf("🔽 Remote [repository: %s, branch: %s]", c.Cfg.Remote.Repo, c.Cfg.Remote.Refs)
+	if err := checkNaming(); err != nil {
+		return err
 	}
+
+	meta := &buildMeta{ConfigHash: config.Hash(), Profile: c.Profile}
+
+	overlayPath, err := profilePath(c.Profile)
+	if err != nil {
+		return fmt.Errorf("failed to resolve profile: %w", err)
+	}
+
+	head, body, overlay, err := openRoots(c.Cfg, overlayPath, !c.NoCache, meta, &c.closers)
 	if err != nil {
 		return fmt.Errorf("filed to setup file system: %w", err)
 	}
-	if bfs == nil {
-		return fmt.Errorf("no file system")
+
+	if err := checkProfile(overlay, c.Profile); err != nil {
+		return err
 	}
 
 	// データベースファイルを作成する
-	if err := createDB(bfs, args[0]); err != nil {
+	if err := createDB(head, body, overlay, args[0], meta); err != nil {
 		return fmt.Errorf("failed to setup: %w", err)
 	}
 
@@ -74,5 +82,8 @@ func (c *g) Run(command *cobra.Command, args []string) (retErr error) {
 }
 
 func (c *g) cleanUp() error {
+	if err := c.closers.Close(); err != nil {
+		return fmt.Errorf("failed to clean up: %w", err)
+	}
 	return nil
 }
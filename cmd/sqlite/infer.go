@@ -0,0 +1,170 @@
+package sqlite
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+	"github.com/tys-muta/go-sqx/cmd/sqlite/config"
+	"github.com/tys-muta/go-sqx/cmd/sqlite/types"
+)
+
+// カラム名から推論した外部キー制約
+type foreignKeySuggestion struct {
+	// 参照元のテーブル名と、設定ファイルに記述する際のキー ( 設定キーが無い場合は索引 )
+	Table string
+	Key   string
+	// 参照先の索引 ( 分割キーを除く )
+	Target     string
+	ForeignKey types.ForeignKey
+	// 参照先の候補が複数ある場合は適用せずに候補を示す
+	Candidates []string
+}
+
+func (s foreignKeySuggestion) String() string {
+	if len(s.Candidates) > 0 {
+		return fmt.Sprintf("%s.%s -> ambiguous: %s", s.Table, strings.Join(s.ForeignKey.Columns, ", "), strings.Join(s.Candidates, ", "))
+	}
+	return fmt.Sprintf("%s.%s -> %s.%s", s.Table, strings.Join(s.ForeignKey.Columns, ", "), s.ForeignKey.Table, strings.Join(s.ForeignKey.References, ", "))
+}
+
+// 設定ファイルにそのまま追記できる形式 ( テーブルのキーは設定キーまたは索引とする )
+func (s foreignKeySuggestion) TOML() string {
+	lines := []string{
+		fmt.Sprintf("# %s", s),
+		fmt.Sprintf("[[table.%s.foreignKeys]]", tomlString(s.Key)),
+		fmt.Sprintf("  columns = [%s]", tomlString(s.ForeignKey.Columns[0])),
+		fmt.Sprintf("  table = %s", tomlString(s.Target)),
+		fmt.Sprintf("  references = [%s]", tomlString(s.ForeignKey.References[0])),
+	}
+	return strings.Join(lines, "\n")
+}
+
+// TOML のリテラル文字列 ( エスケープを解釈しないため re: キーの \ などをそのまま記述できる )
+//
+// リテラル文字列で表せない ' や改行を含む場合は基本文字列とする
+func tomlString(v string) string {
+	if strings.ContainsAny(v, "'\n\r") {
+		return strconv.Quote(v)
+	}
+	return "'" + v + "'"
+}
+
+// カラム名が <テーブル><プライマリーキー> ( e.g. standardId, standard_id ) となるカラムから外部キー制約を推論する
+//
+// 参照先はプライマリーキーが単一のカラムで型が一致するテーブルとし、テーブルは索引の全体 ( e.g. master/item ) または末尾 ( e.g. item ) で対応付ける
+// 外部キー制約が設定済みのカラムと ignoreForeignKeys に指定したカラムは推論しない
+// 参照先の候補が複数ある場合は推論した外部キー制約として適用しない
+func inferForeignKeys(defMap map[string]types.Definition, baseMap map[string]types.Table) []foreignKeySuggestion {
+	names := []string{}
+	for name := range defMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	suggestions := []foreignKeySuggestion{}
+	for _, name := range names {
+		def := defMap[name]
+		table := baseMap[name]
+
+		excluded := map[string]bool{}
+		for _, v := range createOptions(def).ForeignKeys {
+			for _, column := range v.Columns {
+				excluded[column] = true
+			}
+		}
+		for _, v := range table.IgnoreForeignKeys {
+			excluded[v] = true
+			excluded[columnName(v)] = true
+		}
+
+		key := table.Key
+		if key == "" {
+			key = table.ID
+		}
+
+		for _, column := range def.Columns {
+			if excluded[column.Name] {
+				continue
+			}
+
+			candidates := []foreignKeySuggestion{}
+			for _, targetName := range names {
+				if targetName == name {
+					continue
+				}
+				target := defMap[targetName]
+				pk := primaryKeyColumns(target, createOptions(target))
+				if len(pk) != 1 || !hasColumn(target, pk[0]) || findColumn(target, pk[0]).Type != column.Type {
+					continue
+				}
+
+				id := baseMap[targetName].ID
+				for _, prefix := range uniqueStrings(snakeName(strings.Replace(id, "/", "_", -1)), snakeName(path.Base(id))) {
+					if snakeColumn(column.Name) != prefix+"_"+snakeColumn(pk[0]) {
+						continue
+					}
+					candidates = append(candidates, foreignKeySuggestion{
+						Table:  name,
+						Key:    key,
+						Target: id,
+						ForeignKey: types.ForeignKey{
+							Columns:    []string{column.Name},
+							Table:      target.Name,
+							References: []string{pk[0]},
+						},
+					})
+					break
+				}
+			}
+
+			switch {
+			case len(candidates) == 1:
+				suggestions = append(suggestions, candidates[0])
+			case len(candidates) > 1:
+				s := foreignKeySuggestion{Table: name, Key: key, ForeignKey: types.ForeignKey{Columns: []string{column.Name}}}
+				for _, v := range candidates {
+					s.Candidates = append(s.Candidates, fmt.Sprintf("%s.%s", v.ForeignKey.Table, v.ForeignKey.References[0]))
+				}
+				suggestions = append(suggestions, s)
+			}
+		}
+	}
+
+	return suggestions
+}
+
+func snakeName(v string) string {
+	return strcase.ToSnake(v)
+}
+
+// 命名規則の接頭辞を取り除いたカラム名を snake_case で比較する
+func snakeColumn(v string) string {
+	return strcase.ToSnake(strings.TrimPrefix(v, config.Get().Naming.ColumnPrefix))
+}
+
+func findColumn(def types.Definition, name string) types.Column {
+	for _, column := range def.Columns {
+		if column.Name == name {
+			return column
+		}
+	}
+	return types.Column{}
+}
+
+func uniqueStrings(values ...string) []string {
+	unique := []string{}
+	for _, v := range values {
+		found := false
+		for _, w := range unique {
+			found = found || v == w
+		}
+		if !found {
+			unique = append(unique, v)
+		}
+	}
+	return unique
+}
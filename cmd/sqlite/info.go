@@ -0,0 +1,104 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+type i struct {
+	Cmd *cobra.Command
+}
+
+var Info = &i{
+	Cmd: &cobra.Command{
+		Use:   "info",
+		Short: "Print build metadata of SQLite database file",
+		Long: `Prints the build metadata (source, commit, build time, sqx version, config hash
+and source files of each table) embedded in SQLite database file by gen`,
+	},
+}
+
+func init() {
+	Info.Cmd.RunE = Info.Run
+}
+
+func (c *i) Run(command *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("database file is not specified")
+	}
+
+	// 存在しないファイルを指定した場合に空のデータベースが作成されないようにする
+	if _, err := os.Stat(args[0]); err != nil {
+		return fmt.Errorf("failed to stat database file: %w", err)
+	}
+
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?mode=ro", args[0]))
+	if err != nil {
+		return fmt.Errorf("failed to open connection with database")
+	}
+	defer db.Close()
+
+	values, err := readMeta(db)
+	if err != nil {
+		return fmt.Errorf("failed to read metadata: %w", err)
+	}
+
+	out := command.OutOrStdout()
+	for _, v := range values {
+		switch v[0] {
+		case metaKeySources:
+		case metaKeyLayers:
+			layers := []sourceMeta{}
+			if err := json.Unmarshal([]byte(v[1]), &layers); err != nil {
+				return fmt.Errorf("failed to unmarshal layers: %w", err)
+			}
+
+			fmt.Fprintf(out, "%s:\n", v[0])
+			for _, layer := range layers {
+				fmt.Fprintf(out, "  %s:\n", layer.Name)
+				for _, w := range [][]string{
+					{metaKeySourceKind, layer.SourceKind},
+					{metaKeyPath, layer.Path},
+					{metaKeyPrefix, layer.Prefix},
+					{metaKeyRepository, layer.Repository},
+					{metaKeyRefs, layer.Refs},
+					{metaKeyCommit, layer.Commit},
+				} {
+					if w[1] != "" {
+						fmt.Fprintf(out, "    %s: %s\n", w[0], w[1])
+					}
+				}
+			}
+			continue
+		default:
+			fmt.Fprintf(out, "%s: %s\n", v[0], v[1])
+			continue
+		}
+
+		sources := map[string][]sourceFile{}
+		if err := json.Unmarshal([]byte(v[1]), &sources); err != nil {
+			return fmt.Errorf("failed to unmarshal sources: %w", err)
+		}
+
+		names := []string{}
+		for name := range sources {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		fmt.Fprintf(out, "%s:\n", v[0])
+		for _, name := range names {
+			fmt.Fprintf(out, "  %s:\n", name)
+			for _, file := range sources[name] {
+				fmt.Fprintf(out, "    %s %s\n", file.SHA256, file.Path)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,176 @@
+package sqlite
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/tys-muta/go-sqx/cmd/sqlite/config"
+)
+
+// 正規表現で記述する設定キーの接頭辞 ( e.g. table.'re:^event/(?P<eventId>\d+)$' )
+const regexpKeyPrefix = "re:"
+
+// 設定キーの種類 ( 複数のキーに一致する場合は値が大きいものを優先する )
+const (
+	keyKindRegexp = iota + 1
+	keyKindPattern
+	keyKindExact
+)
+
+// テーブルごとの設定キー
+//
+// キーは次のいずれかで記述し、表ファイルの索引 ( 起点となるパスからの拡張子を除いたパス ) の全体に一致するかを判定する
+// - 完全一致 : standard
+// - パターン : * ( / 以外の任意の文字列 ), ? ( / 以外の任意の 1 文字 ), ** ( 0 個以上のディレクトリ ), :name ( ディレクトリ名全体を分割キー ), {name} ( 名前の一部を分割キー )
+// - 正規表現 : re: に続けて記述し、名前付きグループを分割キーとする
+type tableKey struct {
+	key    string
+	kind   int
+	regexp *regexp.Regexp
+	cfg    config.Table
+}
+
+// 設定キーに一致した結果
+type tableMatch struct {
+	key *tableKey
+	// 分割キーの名前と値 ( キー内での出現順 )
+	params [][]string
+	// 索引から分割キーの値を取り除いたもの ( テーブル名の元となる )
+	id string
+}
+
+// 設定キーを優先度の高い順に並べる
+//
+// 1. priority が大きいもの
+// 2. 完全一致、パターン、正規表現の順
+// 3. キーが長いもの
+// 4. キーの辞書順
+func compileTableKeys(tableMap map[string]config.Table) ([]*tableKey, error) {
+	keys := []*tableKey{}
+	for k, v := range tableMap {
+		key, err := compileTableKey(k, v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile table key [%s]: %w", k, err)
+		}
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		switch {
+		case a.cfg.Priority != b.cfg.Priority:
+			return a.cfg.Priority > b.cfg.Priority
+		case a.kind != b.kind:
+			return a.kind > b.kind
+		case len(a.key) != len(b.key):
+			return len(a.key) > len(b.key)
+		default:
+			return a.key < b.key
+		}
+	})
+
+	return keys, nil
+}
+
+func compileTableKey(key string, cfg config.Table) (*tableKey, error) {
+	if strings.HasPrefix(key, regexpKeyPrefix) {
+		re, err := regexp.Compile("^(?:" + strings.TrimPrefix(key, regexpKeyPrefix) + ")$")
+		if err != nil {
+			return nil, err
+		}
+		return &tableKey{key: key, kind: keyKindRegexp, regexp: re, cfg: cfg}, nil
+	}
+
+	// 索引と同様に先頭の / を取り除いて判定する ( e.g. table."/standard" )
+	kind := keyKindExact
+	expr := ""
+	segments := strings.Split(strings.TrimPrefix(key, "/"), "/")
+	for i, segment := range segments {
+		last := i == len(segments)-1
+		switch {
+		case segment == "**" && !last:
+			kind = keyKindPattern
+			expr += `(?:[^/]+/)*`
+			continue
+		case segment == "**":
+			kind = keyKindPattern
+			expr += `.+`
+		case strings.HasPrefix(segment, ":"):
+			kind = keyKindPattern
+			expr += fmt.Sprintf(`(?P<%s>[^/]+)`, strings.TrimPrefix(segment, ":"))
+		default:
+			runes := []rune(segment)
+			for j := 0; j < len(runes); j++ {
+				switch r := runes[j]; r {
+				case '{':
+					// ファイル名の一部を分割キーとする ( e.g. event_{eventDate} )
+					rest := string(runes[j+1:])
+					end := strings.IndexRune(rest, '}')
+					if end < 0 {
+						return nil, fmt.Errorf("unclosed brace: %s", segment)
+					}
+					name := rest[:end]
+					kind = keyKindPattern
+					expr += fmt.Sprintf(`(?P<%s>[^/]+?)`, name)
+					j += len([]rune(name)) + 1
+				case '*':
+					kind = keyKindPattern
+					expr += `[^/]*`
+				case '?':
+					kind = keyKindPattern
+					expr += `[^/]`
+				default:
+					expr += regexp.QuoteMeta(string(r))
+				}
+			}
+		}
+		if !last {
+			expr += "/"
+		}
+	}
+
+	re, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return nil, err
+	}
+
+	return &tableKey{key: key, kind: kind, regexp: re, cfg: cfg}, nil
+}
+
+// 索引が設定キーに一致するかを判定する
+func (k *tableKey) match(index string) (*tableMatch, bool) {
+	index = strings.TrimPrefix(index, "/")
+
+	locs := k.regexp.FindStringSubmatchIndex(index)
+	if locs == nil {
+		return nil, false
+	}
+
+	m := &tableMatch{key: k}
+
+	// 分割キーの値を取り除いた索引を組み立てる
+	id := ""
+	pos := 0
+	for i, name := range k.regexp.SubexpNames() {
+		start, end := locs[2*i], locs[2*i+1]
+		if i == 0 || name == "" || start < 0 || start < pos {
+			continue
+		}
+		m.params = append(m.params, []string{name, index[start:end]})
+		id += index[pos:start]
+		pos = end
+	}
+	id += index[pos:]
+
+	segments := []string{}
+	for _, v := range strings.Split(id, "/") {
+		if v != "" {
+			segments = append(segments, v)
+		}
+	}
+	m.id = strings.Join(segments, "/")
+
+	return m, true
+}
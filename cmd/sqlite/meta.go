@@ -0,0 +1,168 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"time"
+
+	"github.com/tys-muta/go-sqx/cmd/sqlite/types"
+)
+
+// ビルド時に -ldflags "-X github.com/tys-muta/go-sqx/cmd/sqlite.Version=v1.0.0" で埋め込む
+//
+// 空の場合はモジュールのバージョン ( go install でインストールした場合など ) を利用する
+var Version = ""
+
+// データベースに埋め込むビルド情報のテーブル
+const metaTable = "_sqx_meta"
+
+// ビルド情報のキー
+const (
+	metaKeySourceKind = "sourceKind"
+	metaKeyPath       = "path"
+	metaKeyPrefix     = "prefix"
+	metaKeyRepository = "repository"
+	metaKeyRefs       = "refs"
+	metaKeyCommit     = "commit"
+	metaKeyBuildTime  = "buildTime"
+	metaKeyVersion    = "sqxVersion"
+	metaKeyConfigHash = "configHash"
+	metaKeySources    = "sources"
+	metaKeyLayers     = "layers"
+	metaKeyProfile    = "profile"
+)
+
+// 元となるデータの保存先の種類
+const (
+	sourceKindLocal   = "local"
+	sourceKindRemote  = "remote"
+	sourceKindArchive = "archive"
+	// 複数の保存先を重ね合わせたもの
+	sourceKindLayer = "layer"
+)
+
+// データベースがどのデータから生成されたかを示すビルド情報
+type buildMeta struct {
+	sourceMeta
+	// 重ね合わせた保存先 ( 優先度の低い順 )
+	Layers []sourceMeta
+	// 適用したオーバーレイのプロファイル名
+	Profile    string
+	ConfigHash string
+	// テーブル名ごとの表ファイル
+	Sources map[string][]sourceFile
+}
+
+// 元となるデータの保存先の情報
+type sourceMeta struct {
+	Name       string `json:"name,omitempty"`
+	SourceKind string `json:"sourceKind"`
+	Path       string `json:"path,omitempty"`
+	// アーカイブ内のルートとするディレクトリ
+	Prefix string `json:"prefix,omitempty"`
+	// 認証情報を除去したリポジトリの URL
+	Repository string `json:"repository,omitempty"`
+	Refs       string `json:"refs,omitempty"`
+	Commit     string `json:"commit,omitempty"`
+}
+
+type sourceFile struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+}
+
+func (m *buildMeta) addSource(table types.Table) {
+	if m.Sources == nil {
+		m.Sources = map[string][]sourceFile{}
+	}
+	for _, v := range m.Sources[table.Name] {
+		if v.Path == table.Path {
+			return
+		}
+	}
+	m.Sources[table.Name] = append(m.Sources[table.Name], sourceFile{Path: table.Path, SHA256: table.Hash})
+}
+
+func writeMeta(db *sql.DB, meta *buildMeta) error {
+	for _, files := range meta.Sources {
+		sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	}
+	sources, err := json.Marshal(meta.Sources)
+	if err != nil {
+		return fmt.Errorf("failed to marshal sources: %w", err)
+	}
+
+	layers := []byte{}
+	if len(meta.Layers) > 0 {
+		if layers, err = json.Marshal(meta.Layers); err != nil {
+			return fmt.Errorf("failed to marshal layers: %w", err)
+		}
+	}
+
+	query := fmt.Sprintf("CREATE TABLE `%s` (`Key` TEXT NOT NULL, `Value` TEXT NOT NULL, PRIMARY KEY (`Key`))", metaTable)
+	if _, err := db.Exec(query); err != nil {
+		return fmt.Errorf("failed to execute creation query: %w", err)
+	}
+
+	values := [][]string{
+		{metaKeySourceKind, meta.SourceKind},
+		{metaKeyPath, meta.Path},
+		{metaKeyPrefix, meta.Prefix},
+		{metaKeyRepository, meta.Repository},
+		{metaKeyRefs, meta.Refs},
+		{metaKeyCommit, meta.Commit},
+		{metaKeyLayers, string(layers)},
+		{metaKeyProfile, meta.Profile},
+		{metaKeyBuildTime, time.Now().UTC().Format(time.RFC3339)},
+		{metaKeyVersion, version()},
+		{metaKeyConfigHash, meta.ConfigHash},
+		{metaKeySources, string(sources)},
+	}
+	for _, v := range values {
+		if v[1] == "" {
+			continue
+		}
+		query := fmt.Sprintf("INSERT INTO `%s` (`Key`, `Value`) VALUES (?, ?)", metaTable)
+		if _, err := db.Exec(query, v[0], v[1]); err != nil {
+			return fmt.Errorf("failed to execute insertion query: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ビルド情報をキーの登録順に読み込む
+func readMeta(db *sql.DB) ([][]string, error) {
+	rows, err := db.Query(fmt.Sprintf("SELECT `Key`, `Value` FROM `%s` ORDER BY rowid", metaTable))
+	if err != nil {
+		return nil, fmt.Errorf("failed to select: %w", err)
+	}
+	defer rows.Close()
+
+	values := [][]string{}
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, fmt.Errorf("failed to scan: %w", err)
+		}
+		values = append(values, []string{key, value})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate: %w", err)
+	}
+
+	return values, nil
+}
+
+func version() string {
+	if Version != "" {
+		return Version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "(unknown)"
+}
@@ -0,0 +1,100 @@
+package sqlite
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+	"github.com/tys-muta/go-sqx/cmd/sqlite/config"
+	"github.com/tys-muta/go-sqx/cmd/sqlite/types"
+)
+
+// テーブル名とカラム名の命名規則
+//
+// - pascal : PascalCase ( 既定 )
+// - camel : camelCase
+// - snake : snake_case
+// - asis : 表ファイルのまま変換しない
+const (
+	namingPascal = "pascal"
+	namingCamel  = "camel"
+	namingSnake  = "snake"
+	namingAsIs   = "asis"
+)
+
+// 命名規則の設定が対応しているものかを検証する
+func checkNaming() error {
+	naming := config.Get().Naming
+	for _, v := range []string{naming.Table, naming.Column} {
+		switch v {
+		case "", namingPascal, namingCamel, namingSnake, namingAsIs:
+		default:
+			return fmt.Errorf("unsupported naming: %s", v)
+		}
+	}
+	return nil
+}
+
+func convertName(name string, naming string) string {
+	switch naming {
+	case namingCamel:
+		return strcase.ToLowerCamel(name)
+	case namingSnake:
+		return strcase.ToSnake(name)
+	case namingAsIs:
+		return name
+	default:
+		return strcase.ToCamel(name)
+	}
+}
+
+// 索引から分割キーの値を取り除いたもの ( e.g. shard/int ) からテーブル名を生成する
+func tableName(id string) string {
+	naming := config.Get().Naming
+	return naming.TablePrefix + convertName(strings.Replace(id, "/", "_", -1), naming.Table)
+}
+
+// 表ファイルのカラム名からカラム名を生成する
+func columnName(name string) string {
+	naming := config.Get().Naming
+	return naming.ColumnPrefix + convertName(name, naming.Column)
+}
+
+// 命名規則を適用したカラム名が重複していないかを検証する
+func checkColumnCollision(names []string) error {
+	sources := map[string]string{}
+	collisions := []string{}
+	for _, name := range names {
+		v := columnName(name)
+		if w, ok := sources[v]; ok {
+			collisions = append(collisions, fmt.Sprintf("%s (%s, %s)", v, w, name))
+			continue
+		}
+		sources[v] = name
+	}
+	if len(collisions) > 0 {
+		return fmt.Errorf("column name collision: %s", strings.Join(collisions, ", "))
+	}
+	return nil
+}
+
+// 分割キーのカラム名が表ファイルのカラム名と重複していないかを検証する
+func checkShardCollision(shardColumns []types.Column, columns []types.Column) error {
+	for _, v := range shardColumns {
+		for _, w := range columns {
+			if v.Name == w.Name {
+				return fmt.Errorf("column name collision with shard key: %s", v.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// 設定ファイルに記述したカラム名に命名規則を適用する
+func columnNames(names []string) []string {
+	converted := []string{}
+	for _, name := range names {
+		converted = append(converted, columnName(name))
+	}
+	return converted
+}
@@ -0,0 +1,10 @@
+package option
+
+func WithComment(v string) func(any) {
+	return func(options any) {
+		switch o := options.(type) {
+		case *CreateOptions:
+			o.Comment = v
+		}
+	}
+}
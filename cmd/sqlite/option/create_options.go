@@ -8,4 +8,6 @@ type CreateOptions struct {
 	IndexKeys    [][]string
 	ForeignKeys  []types.ForeignKey
 	ShardColumns []types.Column
+	// テーブルの説明
+	Comment string
 }
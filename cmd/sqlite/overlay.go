@@ -0,0 +1,252 @@
+package sqlite
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/tys-muta/go-sqx/cmd/sqlite/config"
+	"github.com/tys-muta/go-sqx/cmd/sqlite/types"
+)
+
+// 行の削除を示すカラム名 ( 未指定の場合 )
+const defaultDeleteColumn = "_delete"
+
+// プロファイルのオーバーレイの表ファイルが配置されたパス ( プロファイルが指定されていない場合は空 )
+func profilePath(profile string) (string, error) {
+	if profile == "" {
+		return "", nil
+	}
+	if profile != path.Base(profile) || profile == "." || profile == ".." {
+		return "", fmt.Errorf("invalid profile: %s", profile)
+	}
+
+	overlayPath := config.Get().Overlay.Path
+	if overlayPath == "" {
+		return "", fmt.Errorf("overlay path is not specified")
+	}
+
+	return path.Join(overlayPath, profile), nil
+}
+
+// プロファイルのディレクトリが存在するかを検証する
+func checkProfile(overlay root, profile string) error {
+	if overlay.FS == nil {
+		return nil
+	}
+
+	info, err := overlay.FS.Stat(overlay.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("profile is not found: %s", profile)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to stat profile: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("profile is not a directory: %s", profile)
+	}
+
+	return nil
+}
+
+// プロファイルのオーバーレイの表ファイルを読み込む ( 対応する表ファイルが存在しない場合はエラーとする )
+func scanOverlays(overlay root, tables []types.Table) (map[string]types.Table, error) {
+	overlayMap := map[string]types.Table{}
+	if overlay.FS == nil {
+		return overlayMap, nil
+	}
+
+	ext := config.Get().Overlay.Ext
+	if ext == "" {
+		ext = config.Get().Body.Ext
+	}
+
+	overlays, err := scanTables(overlay.FS, overlay.Path, ext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan: %w", err)
+	}
+
+	// 起点となるパスの末尾の / の有無によって索引の先頭に / が付くため、取り除いて対応付ける
+	indexes := map[string]bool{}
+	for _, table := range tables {
+		indexes[strings.TrimPrefix(table.Index, "/")] = true
+	}
+
+	for _, v := range overlays {
+		index := strings.TrimPrefix(v.Index, "/")
+		if !indexes[index] {
+			return nil, fmt.Errorf("table to overlay is not found: %s", v.Path)
+		}
+		overlayMap[index] = v
+	}
+
+	return overlayMap, nil
+}
+
+// 環境ごとの差分 ( オーバーレイ ) をレコードに適用する
+//
+// オーバーレイの行はプライマリーキーで元の行と対応付け、空ではないセルの値で上書きする
+// 削除カラムが真の行は元の行を削除し、対応する行が存在しない場合は新しい行として追加する
+// ヘッダー行が無い場合は宣言されたカラムの順番とする ( 削除カラムは指定できない )
+func applyOverlay(table types.Table, columns []types.Column, rows [][]string, overlay types.Table) ([][]string, error) {
+	layout, err := bodyLayout(overlay)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get layout: %w", err)
+	}
+
+	deleteColumn := config.Get().Overlay.DeleteColumn
+	if deleteColumn == "" {
+		deleteColumn = defaultDeleteColumn
+	}
+
+	if overlay.Length() < layout.StartRow-1 {
+		return nil, fmt.Errorf("not enough rows. rows: %d, start row: %d", overlay.Length(), layout.StartRow)
+	}
+
+	columnIndexes := map[string]int{}
+	for i, column := range columns {
+		columnIndexes[column.Name] = i
+	}
+
+	// オーバーレイのカラムと元のカラムを対応付ける
+	deleteIndex := -1
+	overlayIndexes := []int{}
+	if layout.NameRow == 0 {
+		for i := range columns {
+			overlayIndexes = append(overlayIndexes, i)
+		}
+	} else {
+		nameRow, err := overlay.Row(layout.NameRow)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get name row: %w", err)
+		}
+		for i, name := range nameRow {
+			if name == deleteColumn {
+				deleteIndex = i
+				overlayIndexes = append(overlayIndexes, -1)
+				continue
+			}
+			index, ok := columnIndexes[columnName(name)]
+			if !ok {
+				return nil, fmt.Errorf("column is not defined: %s", name)
+			}
+			overlayIndexes = append(overlayIndexes, index)
+		}
+	}
+
+	// プライマリーキーを構成するカラム ( 分割キーはファイル内で同じ値となるため含めない )
+	keyIndexes := []int{}
+	for _, name := range table.PrimaryKey {
+		isShard := false
+		for _, column := range table.ShardColumns {
+			if column.Name == columnName(name) {
+				isShard = true
+			}
+		}
+		if isShard {
+			continue
+		}
+		index, ok := columnIndexes[columnName(name)]
+		if !ok {
+			return nil, fmt.Errorf("primary key is not defined: %s", name)
+		}
+		keyIndexes = append(keyIndexes, index)
+	}
+	if len(keyIndexes) == 0 {
+		// プライマリーキーの指定が無い場合は先頭カラムをプライマリキーにする
+		keyIndexes = append(keyIndexes, 0)
+	}
+	for _, index := range keyIndexes {
+		found := false
+		for _, v := range overlayIndexes {
+			if v == index {
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("primary key column is required: %s", columns[index].Name)
+		}
+	}
+
+	key := func(row []string) string {
+		values := []string{}
+		for _, index := range keyIndexes {
+			if index < len(row) {
+				values = append(values, row[index])
+			} else {
+				values = append(values, "")
+			}
+		}
+		return strings.Join(values, "\x00")
+	}
+
+	rowIndexes := map[string]int{}
+	for i, row := range rows {
+		rowIndexes[key(row)] = i
+	}
+
+	deleted := map[int]bool{}
+	added := [][]string{}
+	for n, overlayRow := range overlay.Rows[layout.StartRow-1:] {
+		row := make([]string, len(columns))
+		present := make([]bool, len(columns))
+		isDelete := false
+		for i, value := range overlayRow {
+			if i >= len(overlayIndexes) {
+				break
+			}
+			if i == deleteIndex {
+				if value == "" {
+					continue
+				}
+				if isDelete, err = strconv.ParseBool(value); err != nil {
+					return nil, fmt.Errorf("invalid delete value. row: %d, value: %s", layout.StartRow+n, value)
+				}
+				continue
+			}
+			// 空のセルは上書きしない
+			if value == "" {
+				continue
+			}
+			row[overlayIndexes[i]] = value
+			present[overlayIndexes[i]] = true
+		}
+
+		index, ok := rowIndexes[key(row)]
+		switch {
+		case isDelete && !ok:
+			return nil, fmt.Errorf("row to delete is not found. row: %d", layout.StartRow+n)
+		case isDelete:
+			deleted[index] = true
+		case ok:
+			for len(rows[index]) < len(columns) {
+				rows[index] = append(rows[index], "")
+			}
+			for i, v := range row {
+				if present[i] {
+					rows[index][i] = v
+				}
+			}
+		default:
+			// 元の行が存在しない場合は全てのカラムが必要となる ( NULL を許容するカラムと既定値があるカラムを除く )
+			for i, v := range present {
+				if !v && !columns[i].IsNullable() && columns[i].Default == "" {
+					return nil, fmt.Errorf("row to add requires all columns. row: %d, column: %s", layout.StartRow+n, columns[i].Name)
+				}
+			}
+			added = append(added, row)
+		}
+	}
+
+	patched := [][]string{}
+	for i, row := range rows {
+		if !deleted[i] {
+			patched = append(patched, row)
+		}
+	}
+
+	return append(patched, added...), nil
+}
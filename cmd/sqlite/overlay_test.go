@@ -0,0 +1,62 @@
+package sqlite
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tys-muta/go-sqx/cmd/sqlite/types"
+)
+
+func TestApplyOverlay(t *testing.T) {
+	columns := []types.Column{
+		{Name: "Id", Type: types.ColumnTypeInteger},
+		{Name: "Name", Type: types.ColumnTypeString},
+		{Name: "Price", Type: types.ColumnTypeInteger, Default: "100"},
+	}
+	rows := func() [][]string {
+		return [][]string{{"1", "a", "10"}, {"2", "b", "20"}, {"3", "c", "30"}}
+	}
+
+	tests := []struct {
+		name    string
+		header  string
+		overlay types.Rows
+		want    [][]string
+	}{
+		{
+			name:   "name",
+			header: headerName,
+			overlay: types.Rows{
+				{"price", "id", "name", "_delete"},
+				{"15", "1", "", ""},
+				{"", "2", "", "true"},
+				{"", "4", "d", ""},
+			},
+			want: [][]string{{"1", "a", "15"}, {"3", "c", "30"}, {"4", "d", ""}},
+		},
+		{
+			name:   "none",
+			header: headerNone,
+			overlay: types.Rows{
+				{"1", "", "15"},
+				{"4", "d", ""},
+			},
+			want: [][]string{{"1", "a", "15"}, {"2", "b", "20"}, {"3", "c", "30"}, {"4", "d", ""}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := types.Table{Header: tt.header, Columns: columns}
+			overlay := types.Table{Rows: tt.overlay, Header: tt.header, Columns: columns}
+
+			got, err := applyOverlay(table, columns, rows(), overlay)
+			if err != nil {
+				t.Fatalf("failed to apply overlay: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -10,6 +10,15 @@ import (
 	"github.com/tys-muta/go-sqx/cmd/sqlite/types"
 )
 
+// カラムの定義に記述する型 ( 括弧を含む型はクォートする )
+func SQLType(column types.Column) string {
+	v := column.Type.SQLType()
+	if strings.Contains(v, "(") {
+		return fmt.Sprintf("`%s`", v)
+	}
+	return v
+}
+
 func Create(tableName string, columns []types.Column, options ...func(any)) (string, error) {
 	if len(columns) == 0 {
 		return "", fmt.Errorf("columns is empty")
@@ -22,26 +31,61 @@ func Create(tableName string, columns []types.Column, options ...func(any)) (str
 
 	body := []string{}
 	for _, column := range columns {
-		body = append(body, fmt.Sprintf("`%s` %s", column.Name, column.Type.AsSQL()))
+		definition := SQLType(column) + " NOT NULL"
+		if column.IsNullable() {
+			definition = SQLType(column) + " NULL"
+		}
+		if column.Default != "" {
+			v, err := cast(column, column.Default)
+			if err != nil {
+				return "", fmt.Errorf("failed to cast default value of column[%s]: %w", column.Name, err)
+			}
+			definition += " DEFAULT " + v
+		}
+		// カラムの説明は DDL のコメントとして残す ( sqlite_master に保存される )
+		if column.Comment != "" {
+			definition += ", -- " + comment(column.Comment) + "\n"
+		} else {
+			definition += ","
+		}
+		body = append(body, fmt.Sprintf("`%s` %s", column.Name, definition))
 	}
+	// カラムの定義は後続の制約との区切りの , を含む
+	constraints := []string{}
 	if len(o.PrimaryKey) > 0 {
 		keys := []string{}
 		for _, v := range o.PrimaryKey {
 			keys = append(keys, fmt.Sprintf("`%s`", v))
 		}
-		body = append(body, fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(keys, ", ")))
+		constraints = append(constraints, fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(keys, ", ")))
 	} else {
 		// プライマリーキーの指定が無い場合は先頭カラムをプライマリキーにする
-		body = append(body, fmt.Sprintf("PRIMARY KEY (`%s`)", columns[0].Name))
+		constraints = append(constraints, fmt.Sprintf("PRIMARY KEY (`%s`)", columns[0].Name))
 	}
 
 	// 外部キー制約
 	for _, v := range o.ForeignKeys {
-		body = append(body, fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s", v.Column, v.Reference))
+		constraint := fmt.Sprintf("FOREIGN KEY (%s) REFERENCES `%s` (%s)", identifiers(v.Columns), v.Table, identifiers(v.References))
+		if v.OnDelete != "" {
+			constraint += " ON DELETE " + v.OnDelete
+		}
+		if v.OnUpdate != "" {
+			constraint += " ON UPDATE " + v.OnUpdate
+		}
+		if v.Deferrable {
+			constraint += " DEFERRABLE INITIALLY DEFERRED"
+		}
+		constraints = append(constraints, constraint)
+	}
+
+	// テーブルの説明は開き括弧の後にコメントとして残す
+	open := "("
+	if o.Comment != "" {
+		open += " -- " + comment(o.Comment) + "\n"
 	}
 
 	queries := []string{}
-	queries = append(queries, fmt.Sprintf("CREATE TABLE `%s` (%s)", tableName, strings.Join(body, ", ")))
+	queries = append(queries, fmt.Sprintf("CREATE TABLE `%s` %s%s %s)", tableName, open, strings.Join(body, " "), strings.Join(constraints, ", ")))
 
 	for _, v := range o.UniqueKeys {
 		queries = append(queries, fmt.Sprintf("CREATE UNIQUE INDEX `%s` ON `%s` (%s)",
@@ -64,3 +108,16 @@ func Create(tableName string, columns []types.Column, options ...func(any)) (str
 
 	return query, nil
 }
+
+// 改行を含む説明がコメントの外に出ないように 1 行にする
+func comment(v string) string {
+	return strings.Join(strings.Fields(v), " ")
+}
+
+func identifiers(names []string) string {
+	v := []string{}
+	for _, name := range names {
+		v = append(v, fmt.Sprintf("`%s`", name))
+	}
+	return strings.Join(v, ", ")
+}
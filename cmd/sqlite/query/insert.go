@@ -2,6 +2,7 @@ package query
 
 import (
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
 	"time"
@@ -44,11 +45,29 @@ func Insert(tableName string, columns []types.Column, rows types.Rows) (string,
 }
 
 func cast(column types.Column, value string) (string, error) {
+	if value == "" && column.Default != "" {
+		value = column.Default
+	}
+	if value == "" && column.Nullable {
+		return "null", nil
+	}
+
 	switch column.Type {
 	case types.ColumnTypeInteger:
 		if value == "" {
 			return "0", nil
 		}
+		// パーセント表記の場合は小数に変換し、整数になる場合のみ許容する ( 200% -> 2 )
+		if strings.HasSuffix(value, "%") {
+			v, err := parsePercent(value)
+			if err != nil {
+				return "", fmt.Errorf("failed to parse int: %w", err)
+			}
+			if v != math.Trunc(v) || math.Abs(v) > math.MaxInt64 {
+				return "", fmt.Errorf("failed to parse int: not an integer: %s", value)
+			}
+			return fmt.Sprintf(`%d`, int64(v)), nil
+		}
 		v, err := strconv.ParseInt(value, 10, 64)
 		if err != nil {
 			return "", fmt.Errorf("failed to parse int: %w", err)
@@ -58,7 +77,8 @@ func cast(column types.Column, value string) (string, error) {
 		if value == "" {
 			return "0", nil
 		}
-		v, err := strconv.ParseFloat(value, 64)
+		// パーセント表記の場合は小数に変換する ( 25% -> 0.25 )
+		v, err := parsePercent(value)
 		if err != nil {
 			return "", fmt.Errorf("failed to parse float, %w", err)
 		}
@@ -89,3 +109,16 @@ func cast(column types.Column, value string) (string, error) {
 		return fmt.Sprintf(`"%s"`, value), nil
 	}
 }
+
+// 数値を解析する ( パーセント表記の場合は 100 で割る )
+func parsePercent(value string) (float64, error) {
+	percent := strings.HasSuffix(value, "%")
+	v, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+	if err != nil {
+		return 0, err
+	}
+	if percent {
+		v /= 100
+	}
+	return v, nil
+}
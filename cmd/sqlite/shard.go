@@ -0,0 +1,148 @@
+package sqlite
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tys-muta/go-sqx/cmd/sqlite/types"
+)
+
+// 分割キーの型
+//
+// - int : 整数
+// - string : 文字列
+// - time : 日時 ( 20060102150405, 2006-01-02T15:04:05, 2006-01-02 15:04:05, RFC3339 など )
+// - date : 日付 ( 20060102, 2006-01-02 ) を 0 時の日時として扱う
+// - enum(a,b,c) : 列挙した値のいずれかの文字列
+const (
+	shardTypeInt    = "int"
+	shardTypeString = "string"
+	// 互換性のために受け付ける ( string と同様 )
+	shardTypeNullString = "null_string"
+	shardTypeTime       = "time"
+	shardTypeDate       = "date"
+	shardTypeEnum       = "enum"
+)
+
+// タイムゾーンを含まない日時のフォーマット ( 書き込み時に設定ファイルのタイムゾーンを適用する )
+const shardTimeFormat = "2006-01-02 15:04:05"
+
+var shardTimeLayouts = []string{
+	"20060102150405",
+	"20060102T150405",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02_15-04-05",
+	"200601021504",
+	"2006-01-02T15:04",
+}
+
+var shardDateLayouts = []string{
+	"20060102",
+	"2006-01-02",
+	"2006_01_02",
+}
+
+// 分割キーの値を型に合わせて変換したカラムを返す ( 値が型に合わない場合はエラーとする )
+func shardColumn(name string, shardType string, value string) (types.Column, error) {
+	column := types.Column{Name: columnName(name), Value: value}
+
+	switch {
+	case shardType == shardTypeInt:
+		column.Type = types.ColumnTypeInteger
+		if _, err := strconv.Atoi(value); err != nil {
+			return column, fmt.Errorf("invalid int shard value. name: %s, value: %s", name, value)
+		}
+	case shardType == shardTypeString, shardType == shardTypeNullString:
+		column.Type = types.ColumnType(shardType)
+	case shardType == shardTypeTime:
+		column.Type = types.ColumnTypeDateTime
+		if v, err := time.Parse(time.RFC3339, value); err == nil {
+			column.Value = v.Format(time.RFC3339)
+			return column, nil
+		}
+		v, err := parseTime(value, shardTimeLayouts)
+		if err != nil {
+			return column, fmt.Errorf("invalid time shard value. name: %s, value: %s", name, value)
+		}
+		column.Value = v.Format(shardTimeFormat)
+	case shardType == shardTypeDate:
+		column.Type = types.ColumnTypeDateTime
+		v, err := parseTime(value, shardDateLayouts)
+		if err != nil {
+			return column, fmt.Errorf("invalid date shard value. name: %s, value: %s", name, value)
+		}
+		column.Value = v.Format(shardTimeFormat)
+	case strings.HasPrefix(shardType, shardTypeEnum+"(") && strings.HasSuffix(shardType, ")"):
+		column.Type = types.ColumnTypeString
+		values := strings.TrimSuffix(strings.TrimPrefix(shardType, shardTypeEnum+"("), ")")
+		for _, v := range strings.Split(values, ",") {
+			column.Enum = append(column.Enum, strings.TrimSpace(v))
+		}
+		for _, v := range column.Enum {
+			if v == value {
+				return column, nil
+			}
+		}
+		return column, fmt.Errorf("invalid enum shard value. name: %s, value: %s, values: %s", name, value, values)
+	default:
+		return column, fmt.Errorf("unsupported shard type: %s", shardType)
+	}
+
+	return column, nil
+}
+
+func parseTime(value string, layouts []string) (time.Time, error) {
+	for _, layout := range layouts {
+		if v, err := time.Parse(layout, value); err == nil {
+			return v, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unsupported format: %s", value)
+}
+
+// 分割されたテーブルの基準となるカラムとの差異を返す
+//
+// byName が真の場合はカラムの順番の違いを差異としない
+func diffColumns(base []types.Column, columns []types.Column, byName bool) []string {
+	diffs := []string{}
+
+	baseMap := map[string]types.Column{}
+	for _, v := range base {
+		baseMap[v.Name] = v
+	}
+	columnMap := map[string]types.Column{}
+	for _, v := range columns {
+		columnMap[v.Name] = v
+	}
+
+	for _, v := range base {
+		if _, ok := columnMap[v.Name]; !ok {
+			diffs = append(diffs, fmt.Sprintf("missing column: %s", v.Name))
+		}
+	}
+	for _, v := range columns {
+		w, ok := baseMap[v.Name]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("extra column: %s", v.Name))
+			continue
+		}
+		if w.Type != v.Type {
+			diffs = append(diffs, fmt.Sprintf("mismatch type of column: %s (base: %s, shard: %s)", v.Name, w.Type, v.Type))
+		}
+	}
+	if len(diffs) > 0 || byName {
+		return diffs
+	}
+
+	for i := range base {
+		if i >= len(columns) || base[i].Name != columns[i].Name {
+			diffs = append(diffs, fmt.Sprintf("mismatch order of columns at %d (base: %s)", i+1, base[i].Name))
+			break
+		}
+	}
+
+	return diffs
+}
@@ -0,0 +1,222 @@
+package sqlite
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/helper/chroot"
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/tys-muta/go-sqx/archive"
+	"github.com/tys-muta/go-sqx/cmd/sqlite/config"
+	"github.com/tys-muta/go-sqx/fs"
+	"github.com/tys-muta/go-sqx/git"
+	git_option "github.com/tys-muta/go-sqx/git/option"
+)
+
+// 元となるデータの保存先によってファイルシステムを切り替える
+//
+// paths は Git リポジトリから読み込む場合に対象とするパス
+// 読み込みの終了時に closers に追加した解放処理 ( クローンした一時ディレクトリの削除など ) を呼び出す
+func openSource(local config.Local, arc config.Archive, remote config.Remote, paths []string, useCache bool, closers *closers) (billy.Filesystem, sourceMeta, error) {
+	var bfs billy.Filesystem
+	var snapshot *git.Snapshot
+	var err error
+	meta := sourceMeta{}
+	switch {
+	case arc.Path != "":
+		bfs, err = archive.Open(arc.Path, arc.Prefix)
+		meta.SourceKind, meta.Path, meta.Prefix = sourceKindArchive, arc.Path, arc.Prefix
+		log.Printf("🔽 Archive [path: %s, prefix: %s]", arc.Path, arc.Prefix)
+	case local.Path != "" && local.Refs != "":
+		snapshot, err = git.Open(local.Path, local.Refs, git_option.WithPaths(paths...))
+		meta.SourceKind, meta.Path, meta.Refs = sourceKindLocal, local.Path, local.Refs
+		log.Printf("🔽 Local repository [path: %s, refs: %s]", local.Path, local.Refs)
+	case local.Path != "":
+		bfs = osfs.New(local.Path)
+		meta.SourceKind, meta.Path = sourceKindLocal, local.Path
+		log.Printf("🔽 Local [path: %s]", local.Path)
+	case remote.Repo != "":
+		snapshot, err = clone(remote, paths, useCache)
+		meta.SourceKind, meta.Repository, meta.Refs = sourceKindRemote, git.StripCredential(remote.Repo), remote.Refs
+		log.Printf("🔽 Remote [repository: %s, refs: %s]", meta.Repository, remote.Refs)
+	}
+	if err != nil {
+		return nil, meta, err
+	}
+	if snapshot != nil {
+		closers.add(snapshot.Close)
+		bfs = snapshot
+		meta.Commit = snapshot.Hash.String()
+		log.Printf("🔽 Commit [%s]", meta.Commit)
+	}
+	if bfs == nil {
+		return nil, meta, fmt.Errorf("no file system")
+	}
+
+	return bfs, meta, nil
+}
+
+// 設定ファイルの保存先からヘッダー、レコード、オーバーレイの表ファイルを読み込むルートを用意する
+//
+// 複数の保存先が指定されている場合は重ね合わせ、保存先の情報を meta に記録する
+func openRoots(cfg config.Config, overlayPath string, useCache bool, meta *buildMeta, closers *closers) (root, root, root, error) {
+	if len(cfg.Sources) > 0 {
+		head, body, overlay, layers, err := mountSources(cfg.Sources, overlayPath, useCache, closers)
+		if err != nil {
+			return root{}, root{}, root{}, err
+		}
+		meta.SourceKind = sourceKindLayer
+		meta.Layers = layers
+		return head, body, overlay, nil
+	}
+
+	paths := []string{cfg.Head.Path, cfg.Body.Path}
+	if overlayPath != "" {
+		paths = append(paths, overlayPath)
+	}
+	bfs, source, err := openSource(cfg.Local, cfg.Archive, cfg.Remote, paths, useCache, closers)
+	if err != nil {
+		return root{}, root{}, root{}, err
+	}
+	meta.sourceMeta = source
+
+	overlay := root{}
+	if overlayPath != "" {
+		overlay = root{FS: bfs, Path: overlayPath}
+	}
+
+	return root{FS: bfs, Path: cfg.Head.Path}, root{FS: bfs, Path: cfg.Body.Path}, overlay, nil
+}
+
+// 読み込みの終了時に呼び出す解放処理
+type closers []func() error
+
+func (c *closers) add(close func() error) {
+	*c = append(*c, close)
+}
+
+// 追加した順と逆順に解放し、最初に発生したエラーを返す
+func (c *closers) Close() error {
+	var err error
+	for i := len(*c) - 1; i >= 0; i-- {
+		if v := (*c)[i](); v != nil && err == nil {
+			err = v
+		}
+	}
+	*c = nil
+	return err
+}
+
+// 複数の保存先の表ファイルを重ね合わせる
+//
+// 保存先ごとに表ファイルの起点となるパスをルートとし、後に指定した保存先ほど優先する
+// オーバーレイは全ての保存先で共通のパス ( overlayPath ) をルートとする
+// 同じテーブルが複数の保存先で定義されている場合、優先する側で上書きが許可されていなければエラーとする
+func mountSources(sources []config.Source, overlayPath string, useCache bool, closers *closers) (root, root, root, []sourceMeta, error) {
+	head := config.Get().Head
+	body := config.Get().Body
+
+	names := []string{}
+	headLayers := []billy.Filesystem{}
+	bodyLayers := []billy.Filesystem{}
+	overlayLayers := []billy.Filesystem{}
+	metaList := []sourceMeta{}
+	for i, source := range sources {
+		name := source.Name
+		if name == "" {
+			name = fmt.Sprintf("sources[%d]", i)
+		}
+
+		headPath := source.Head.Path
+		if headPath == "" {
+			headPath = head.Path
+		}
+		bodyPath := source.Body.Path
+		if bodyPath == "" {
+			bodyPath = body.Path
+		}
+
+		log.Printf("🔽 Source [name: %s]", name)
+		paths := []string{headPath, bodyPath}
+		if overlayPath != "" {
+			paths = append(paths, overlayPath)
+		}
+
+		bfs, meta, err := openSource(source.Local, source.Archive, source.Remote, paths, useCache, closers)
+		if err != nil {
+			return root{}, root{}, root{}, nil, fmt.Errorf("failed to setup file system [%s]: %w", name, err)
+		}
+		meta.Name = name
+
+		names = append(names, name)
+		headLayers = append(headLayers, chroot.New(bfs, headPath))
+		bodyLayers = append(bodyLayers, chroot.New(bfs, bodyPath))
+		overlayLayers = append(overlayLayers, chroot.New(bfs, overlayPath))
+		metaList = append(metaList, meta)
+	}
+
+	if err := checkOverride(sources, names, headLayers, head.Ext); err != nil {
+		return root{}, root{}, root{}, nil, err
+	}
+	if err := checkOverride(sources, names, bodyLayers, body.Ext); err != nil {
+		return root{}, root{}, root{}, nil, err
+	}
+
+	overlay := root{}
+	if overlayPath != "" {
+		overlay.FS = fs.NewLayer(overlayLayers...)
+	}
+
+	return root{FS: fs.NewLayer(headLayers...)}, root{FS: fs.NewLayer(bodyLayers...)}, overlay, metaList, nil
+}
+
+// 同じテーブルが複数の保存先に存在する場合、優先する保存先で上書きが許可されているかを検証する
+//
+// 分割されたテーブルは表ファイルが異なっても同じテーブルとするため、設定キーを適用したテーブルの ID で判定する
+func checkOverride(sources []config.Source, names []string, layers []billy.Filesystem, ext string) error {
+	keys, err := compileTableKeys(config.Get().Table)
+	if err != nil {
+		return fmt.Errorf("failed to compile table keys: %w", err)
+	}
+
+	owners := map[string]int{}
+	for i, layer := range layers {
+		fileMap, err := fs.Read(layer, "", ext)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read [%s]: %w", names[i], err)
+		}
+
+		ids := []string{}
+		for index := range fileMap {
+			ids = append(ids, tableID(keys, index))
+		}
+		sort.Strings(ids)
+
+		for _, id := range ids {
+			if j, ok := owners[id]; ok && j != i && !sources[i].Override {
+				return fmt.Errorf("table is defined in multiple sources. table: %s, sources: %s, %s", id, names[j], names[i])
+			}
+			owners[id] = i
+		}
+	}
+
+	return nil
+}
+
+// 索引に一致する設定キーがあれば分割キーの値を取り除いたテーブルの ID を返す
+func tableID(keys []*tableKey, index string) string {
+	for _, key := range keys {
+		if m, ok := key.match(index); ok {
+			return m.id
+		}
+	}
+	return strings.TrimPrefix(index, "/")
+}
@@ -0,0 +1,117 @@
+package table
+
+import (
+	b "bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/tys-muta/go-sqx/cmd/sqlite/types"
+)
+
+// オブジェクトの配列で構成される JSON ファイルのパーサー
+type jsonParser struct{}
+
+var _ parser = (*jsonParser)(nil)
+
+func (p *jsonParser) Parse(bytes []byte) (types.Rows, error) {
+	decoder := json.NewDecoder(b.NewReader(bytes))
+	decoder.UseNumber()
+
+	if err := expectDelim(decoder, '['); err != nil {
+		return nil, fmt.Errorf("failed to read json file: %w", err)
+	}
+
+	records := []record{}
+	for decoder.More() {
+		r, err := decodeJSONObject(decoder)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read json file: %w", err)
+		}
+		records = append(records, r)
+	}
+
+	if err := expectDelim(decoder, ']'); err != nil {
+		return nil, fmt.Errorf("failed to read json file: %w", err)
+	}
+
+	return recordsToRows(records), nil
+}
+
+// キーの順序を保ったまま JSON オブジェクトを読み込む
+func decodeJSONObject(decoder *json.Decoder) (record, error) {
+	r := record{values: map[string]string{}}
+
+	if err := expectDelim(decoder, '{'); err != nil {
+		return r, err
+	}
+
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return r, fmt.Errorf("failed to read key: %w", err)
+		}
+		key, ok := token.(string)
+		if !ok {
+			return r, fmt.Errorf("unexpected key: %v", token)
+		}
+
+		raw := json.RawMessage{}
+		if err := decoder.Decode(&raw); err != nil {
+			return r, fmt.Errorf("failed to read value of %s: %w", key, err)
+		}
+
+		value, err := jsonValue(raw)
+		if err != nil {
+			return r, fmt.Errorf("failed to read value of %s: %w", key, err)
+		}
+
+		if _, ok := r.values[key]; !ok {
+			r.keys = append(r.keys, key)
+		}
+		r.values[key] = value
+	}
+
+	if err := expectDelim(decoder, '}'); err != nil {
+		return r, err
+	}
+
+	return r, nil
+}
+
+// JSON の値を表ファイルのセルと同じ文字列に変換する
+//
+// null は空文字、文字列はそのまま、それ以外 ( 数値、真偽値、オブジェクト、配列 ) は JSON 表現とする
+func jsonValue(raw json.RawMessage) (string, error) {
+	raw = b.TrimSpace(raw)
+	switch {
+	case string(raw) == "null":
+		return "", nil
+	case len(raw) > 0 && raw[0] == '"':
+		value := ""
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return "", err
+		}
+		return value, nil
+	default:
+		buffer := b.Buffer{}
+		if err := json.Compact(&buffer, raw); err != nil {
+			return "", err
+		}
+		return buffer.String(), nil
+	}
+}
+
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		if err == io.EOF {
+			return fmt.Errorf("unexpected end of json, expected %s", delim)
+		}
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("unexpected token: %v, expected %s", token, delim)
+	}
+	return nil
+}
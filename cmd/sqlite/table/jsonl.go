@@ -0,0 +1,35 @@
+package table
+
+import (
+	b "bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/tys-muta/go-sqx/cmd/sqlite/types"
+)
+
+// 1 行に 1 オブジェクトを記述する JSON Lines ファイルのパーサー
+type jsonlParser struct{}
+
+var _ parser = (*jsonlParser)(nil)
+
+func (p *jsonlParser) Parse(bytes []byte) (types.Rows, error) {
+	records := []record{}
+	for i, line := range b.Split(bytes, []byte("\n")) {
+		line = b.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		decoder := json.NewDecoder(b.NewReader(line))
+		decoder.UseNumber()
+
+		r, err := decodeJSONObject(decoder)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read jsonl file at line %d: %w", i+1, err)
+		}
+		records = append(records, r)
+	}
+
+	return recordsToRows(records), nil
+}
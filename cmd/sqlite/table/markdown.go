@@ -0,0 +1,129 @@
+// markdown は GitHub Flavored Markdown のパイプテーブルを表として読み込む
+//
+// ヘッダー行と区切り行の次から始まるデータ行を、区切り行を除いて表ファイルの行として扱うため、
+// カラム名やカラムの型の行は他の表ファイルと同様に設定ファイルの行数に従う
+package table
+
+import (
+	"bufio"
+	b "bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/tys-muta/go-sqx/cmd/sqlite/config"
+	"github.com/tys-muta/go-sqx/cmd/sqlite/types"
+)
+
+type markdownParser struct{}
+
+var _ parser = (*markdownParser)(nil)
+
+var (
+	markdownDelimiterCell = regexp.MustCompile(`^:?-+:?$`)
+	markdownHeading       = regexp.MustCompile(`^ {0,3}(#{1,6})\s+(.*?)\s*#*\s*$`)
+)
+
+func (p *markdownParser) Parse(bytes []byte) (types.Rows, error) {
+	lines := []string{}
+	scanner := bufio.NewScanner(b.NewReader(bytes))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(bytes)+1)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read markdown file: %w", err)
+	}
+
+	// 見出しの指定が無い場合は最初のテーブルを対象とする
+	name := config.Get().Markdown.Table
+
+	heading := ""
+	inFence := false
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+
+		// コードブロック内のテーブルは対象外とする
+		if strings.HasPrefix(line, "```") || strings.HasPrefix(line, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+
+		if m := markdownHeading.FindStringSubmatch(lines[i]); m != nil {
+			heading = m[2]
+			continue
+		}
+
+		if !strings.Contains(line, "|") || i+1 >= len(lines) || !p.isDelimiterRow(lines[i+1]) {
+			continue
+		}
+		if name != "" && heading != name {
+			continue
+		}
+
+		rows := types.Rows{p.splitRow(lines[i])}
+		for _, line := range lines[i+2:] {
+			if strings.TrimSpace(line) == "" || !strings.Contains(line, "|") {
+				break
+			}
+			rows = append(rows, p.splitRow(line))
+		}
+
+		// 各行の長さをヘッダー行に揃える
+		for j, row := range rows {
+			values := make([]string, len(rows[0]))
+			copy(values, row)
+			rows[j] = values
+		}
+
+		return rows, nil
+	}
+
+	if name != "" {
+		return nil, fmt.Errorf("table under heading [%s] does not exist", name)
+	}
+
+	return types.Rows{}, nil
+}
+
+func (p *markdownParser) isDelimiterRow(line string) bool {
+	if !strings.Contains(line, "-") {
+		return false
+	}
+	for _, cell := range p.splitRow(line) {
+		if !markdownDelimiterCell.MatchString(cell) {
+			return false
+		}
+	}
+	return true
+}
+
+// 行をセルに分割する ( 先頭と末尾のパイプは省略可能で、\| はパイプ文字として扱う )
+func (p *markdownParser) splitRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = strings.TrimSuffix(line, "|")
+	}
+
+	cells := []string{}
+	builder := strings.Builder{}
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			builder.WriteByte('|')
+			i++
+		case line[i] == '|':
+			cells = append(cells, strings.TrimSpace(builder.String()))
+			builder.Reset()
+		default:
+			builder.WriteByte(line[i])
+		}
+	}
+	cells = append(cells, strings.TrimSpace(builder.String()))
+
+	return cells
+}
@@ -0,0 +1,25 @@
+package table
+
+import "strconv"
+
+// 数値を以下のルールで正規化する
+// - 表計算ソフトの表示精度 ( 有効数字 15 桁 ) を超える誤差は丸める
+// - 整数は小数点や指数表記を含まない形式にする ( 1.0 -> 1, 1E+20 -> 100000000000000000000 )
+// - 小数は指数表記を含まない形式にする ( 1.5E-7 -> 0.00000015 )
+// - パーセント書式の場合は 100 倍して % を付与する ( 0.25 -> 25% )
+func normalizeNumber(v float64, percent bool) string {
+	if percent {
+		v *= 100
+	}
+
+	if rounded, err := strconv.ParseFloat(strconv.FormatFloat(v, 'g', 15, 64), 64); err == nil {
+		v = rounded
+	}
+
+	value := strconv.FormatFloat(v, 'f', -1, 64)
+	if percent {
+		value += "%"
+	}
+
+	return value
+}
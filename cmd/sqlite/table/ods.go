@@ -0,0 +1,317 @@
+// ods は LibreOffice などで保存された OpenDocument Spreadsheet を content.xml から直接読み込む
+package table
+
+import (
+	"archive/zip"
+	b "bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tys-muta/go-sqx/cmd/sqlite/config"
+	"github.com/tys-muta/go-sqx/cmd/sqlite/types"
+)
+
+type odsParser struct{}
+
+var _ parser = (*odsParser)(nil)
+
+const (
+	odsNamespaceTable  = "urn:oasis:names:tc:opendocument:xmlns:table:1.0"
+	odsNamespaceOffice = "urn:oasis:names:tc:opendocument:xmlns:office:1.0"
+	odsNamespaceText   = "urn:oasis:names:tc:opendocument:xmlns:text:1.0"
+)
+
+// time-value の期間 ( 符号, 日, 時, 分, 秒 )
+var odsTimePattern = regexp.MustCompile(`^(-?)P(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+
+func (p *odsParser) Parse(bytes []byte) (types.Rows, error) {
+	reader, err := zip.NewReader(b.NewReader(bytes), int64(len(bytes)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ods file: %w", err)
+	}
+
+	var content io.ReadCloser
+	for _, f := range reader.File {
+		if f.Name != "content.xml" {
+			continue
+		}
+		if content, err = f.Open(); err != nil {
+			return nil, fmt.Errorf("failed to open content.xml: %w", err)
+		}
+		break
+	}
+	if content == nil {
+		return nil, fmt.Errorf("content.xml does not exist in ods file")
+	}
+	defer content.Close()
+
+	rows, err := p.parseContent(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse content.xml: %w", err)
+	}
+
+	return rows, nil
+}
+
+// 対象シートの行を読み込む
+//
+// 末尾の空セルや空行は number-columns-repeated などで大量に繰り返されることがあるため、
+// 値のあるセルや行が後続する場合にのみ展開する
+func (p *odsParser) parseContent(content io.Reader) (types.Rows, error) {
+	decoder := xml.NewDecoder(content)
+
+	// シート名の指定が無い場合は xlsx と同じシート名を対象とする
+	sheet := config.Get().ODS.Sheet
+	if sheet == "" {
+		sheet = config.Get().XLSX.Sheet
+	}
+
+	rows := types.Rows{}
+	inSheet := false
+	pendingRows := 0
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read token: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch {
+			case t.Name.Space == odsNamespaceTable && t.Name.Local == "table":
+				inSheet = attr(t, odsNamespaceTable, "name") == sheet
+			case inSheet && t.Name.Space == odsNamespaceTable && t.Name.Local == "table-row":
+				row, err := p.parseRow(decoder)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse row[%d]: %w", rows.Length()+pendingRows+1, err)
+				}
+				repeated := repeat(t, "number-rows-repeated")
+				if len(row) == 0 {
+					pendingRows += repeated
+					continue
+				}
+				for ; pendingRows > 0; pendingRows-- {
+					rows = append(rows, []string{})
+				}
+				for i := 0; i < repeated; i++ {
+					rows = append(rows, append([]string{}, row...))
+				}
+			}
+		case xml.EndElement:
+			if inSheet && t.Name.Space == odsNamespaceTable && t.Name.Local == "table" {
+				return p.normalize(rows), nil
+			}
+		}
+	}
+
+	return p.normalize(rows), nil
+}
+
+// table-row 要素の開始直後から終了までを読み込み、セルの値を返す
+func (p *odsParser) parseRow(decoder *xml.Decoder) ([]string, error) {
+	values := []string{}
+	pendingCells := 0
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read token: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Space != odsNamespaceTable || (t.Name.Local != "table-cell" && t.Name.Local != "covered-table-cell") {
+				if err := decoder.Skip(); err != nil {
+					return nil, fmt.Errorf("failed to skip element: %w", err)
+				}
+				continue
+			}
+			value, err := p.parseCell(decoder, t)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse cell[%d]: %w", len(values)+pendingCells+1, err)
+			}
+			repeated := repeat(t, "number-columns-repeated")
+			if value == "" {
+				pendingCells += repeated
+				continue
+			}
+			for ; pendingCells > 0; pendingCells-- {
+				values = append(values, "")
+			}
+			for i := 0; i < repeated; i++ {
+				values = append(values, value)
+			}
+		case xml.EndElement:
+			return values, nil
+		}
+	}
+}
+
+// セルの値を取得する
+//
+// 日付はタイムゾーンを含まない "2006-01-02 15:04:05" 形式、時間は "15:04:05" 形式とし、
+// 数値とパーセントは xlsx と同じルールで正規化する
+func (p *odsParser) parseCell(decoder *xml.Decoder, start xml.StartElement) (string, error) {
+	text, err := p.parseText(decoder)
+	if err != nil {
+		return "", err
+	}
+
+	switch attr(start, odsNamespaceOffice, "value-type") {
+	case "date":
+		value := attr(start, odsNamespaceOffice, "date-value")
+		for _, layout := range []string{"2006-01-02T15:04:05.999999999", "2006-01-02"} {
+			if t, err := time.Parse(layout, value); err == nil {
+				return t.Format("2006-01-02 15:04:05"), nil
+			}
+		}
+		return "", fmt.Errorf("failed to parse date: %s", value)
+	case "time":
+		value := attr(start, odsNamespaceOffice, "time-value")
+		v, err := parseODSTime(value)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse time: %w", err)
+		}
+		return v, nil
+	case "float", "currency", "percentage":
+		value := attr(start, odsNamespaceOffice, "value")
+		float, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse float: %w", err)
+		}
+		return normalizeNumber(float, attr(start, odsNamespaceOffice, "value-type") == "percentage"), nil
+	case "boolean":
+		return attr(start, odsNamespaceOffice, "boolean-value"), nil
+	default:
+		return text, nil
+	}
+}
+
+// セル要素の終了までを読み込み、段落を改行で連結したテキストを返す
+func (p *odsParser) parseText(decoder *xml.Decoder) (string, error) {
+	paragraphs := []string{}
+	builder := strings.Builder{}
+	depth := 0
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", fmt.Errorf("failed to read token: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			// セルのコメントは値に含めない
+			if t.Name.Space == odsNamespaceOffice && t.Name.Local == "annotation" {
+				if err := decoder.Skip(); err != nil {
+					return "", fmt.Errorf("failed to skip annotation: %w", err)
+				}
+				continue
+			}
+			depth++
+			if t.Name.Space != odsNamespaceText {
+				continue
+			}
+			switch t.Name.Local {
+			case "p":
+				builder.Reset()
+			case "s":
+				builder.WriteString(strings.Repeat(" ", repeat(t, "c")))
+			case "tab":
+				builder.WriteString("\t")
+			case "line-break":
+				builder.WriteString("\n")
+			}
+		case xml.CharData:
+			if depth > 0 {
+				builder.Write(t)
+			}
+		case xml.EndElement:
+			if depth == 0 {
+				return strings.Join(paragraphs, "\n"), nil
+			}
+			depth--
+			if t.Name.Space == odsNamespaceText && t.Name.Local == "p" {
+				paragraphs = append(paragraphs, builder.String())
+			}
+		}
+	}
+}
+
+// time-value の ISO 8601 形式の期間 ( e.g. PT13H45M00S ) を "15:04:05" 形式にする ( 24 時間以上の場合は時間を繰り上げない )
+func parseODSTime(value string) (string, error) {
+	m := odsTimePattern.FindStringSubmatch(value)
+	if m == nil {
+		return "", fmt.Errorf("unsupported format: %s", value)
+	}
+
+	values := []float64{}
+	for _, v := range m[2:] {
+		if v == "" {
+			values = append(values, 0)
+			continue
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return "", fmt.Errorf("unsupported format: %s", value)
+		}
+		values = append(values, f)
+	}
+
+	seconds := int(values[0]*86400 + values[1]*3600 + values[2]*60 + values[3])
+	return fmt.Sprintf("%s%02d:%02d:%02d", m[1], seconds/3600, seconds/60%60, seconds%60), nil
+}
+
+// xlsx と同様に、カラム名が空の列を除外し、各行の長さをカラム数に揃える
+func (p *odsParser) normalize(rows types.Rows) types.Rows {
+	nameRow := config.Get().Head.ColumnNameRow
+	if nameRow == 0 || rows.Length() < nameRow {
+		return rows
+	}
+
+	names := rows[nameRow-1]
+	for i, row := range rows {
+		values := make([]string, 0, len(names))
+		for j, name := range names {
+			if name == "" {
+				continue
+			}
+			if j < len(row) {
+				values = append(values, row[j])
+			} else {
+				values = append(values, "")
+			}
+		}
+		rows[i] = values
+	}
+
+	return rows
+}
+
+func attr(element xml.StartElement, space string, local string) string {
+	for _, v := range element.Attr {
+		if v.Name.Space == space && v.Name.Local == local {
+			return v.Value
+		}
+	}
+	return ""
+}
+
+// 繰り返し回数を表す属性を取得する ( 未指定の場合は 1 )
+func repeat(element xml.StartElement, local string) int {
+	for _, v := range element.Attr {
+		if v.Name.Local != local {
+			continue
+		}
+		if n, err := strconv.Atoi(v.Value); err == nil && n > 0 {
+			return n
+		}
+	}
+	return 1
+}
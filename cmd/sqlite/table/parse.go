@@ -1,13 +1,23 @@
 package table
 
 import (
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/go-git/go-billy/v5"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/tys-muta/go-sqx/cmd/sqlite/config"
 	"github.com/tys-muta/go-sqx/cmd/sqlite/types"
 	"github.com/tys-muta/go-sqx/fs"
 )
 
+// 表ファイルと同じディレクトリに配置するスキーマファイルの接尾辞 ( e.g. foo.json に対して foo.schema.toml )
+const schemaSuffix = ".schema.toml"
+
 func Parse(bfs billy.Basic, file fs.File) (types.Rows, error) {
 	bytes := make([]byte, file.Size)
 
@@ -33,3 +43,59 @@ func Parse(bfs billy.Basic, file fs.File) (types.Rows, error) {
 
 	return data, nil
 }
+
+// カラムの型が未定義のセルにスキーマの型を適用する
+func ApplyColumnTypes(rows types.Rows, columnTypes map[string]string) {
+	head := config.Get().Head
+	if head.ColumnNameRow == 0 || head.ColumnTypeRow == 0 || rows.Length() < head.ColumnNameRow || rows.Length() < head.ColumnTypeRow {
+		return
+	}
+
+	nameRow := rows[head.ColumnNameRow-1]
+	typeRow := rows[head.ColumnTypeRow-1]
+	for i := range typeRow {
+		if typeRow[i] != "" || len(nameRow) <= i {
+			continue
+		}
+		typeRow[i] = columnTypes[nameRow[i]]
+	}
+}
+
+// 型の行を持たない形式 ( .json, .jsonl, .yaml ) のみ、表ファイルと同じディレクトリのスキーマファイルを読み込む
+func HasSchemaFile(file fs.File) bool {
+	switch file.Type {
+	case fs.FileTypeJSON, fs.FileTypeJSONL, fs.FileTypeYAML, fs.FileTypeYML:
+		return true
+	default:
+		return false
+	}
+}
+
+// 表ファイルと同じディレクトリに配置するスキーマファイルのパス
+func SchemaPath(file fs.File) string {
+	return strings.TrimSuffix(file.Path, filepath.Ext(file.Path)) + schemaSuffix
+}
+
+// スキーマファイル ( テーブルごとの設定と同じ形式 ) を読み込む ( 存在しない場合は nil を返す )
+func ReadSchema(bfs billy.Basic, path string) (*config.Table, error) {
+	f, err := bfs.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open schema file: %w", err)
+	}
+	defer f.Close()
+
+	bytes, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema file: %w", err)
+	}
+
+	schema := config.Table{}
+	if err := toml.Unmarshal(bytes, &schema); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal schema file: %w", err)
+	}
+
+	return &schema, nil
+}
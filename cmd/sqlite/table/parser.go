@@ -15,10 +15,20 @@ func NewParser(fileType fs.FileType) (parser, error) {
 	switch fileType {
 	case fs.FileTypeXLSX:
 		return &xlsxParser{}, nil
+	case fs.FileTypeODS:
+		return &odsParser{}, nil
 	case fs.FileTypeCSV:
 		return &csvParser{}, nil
 	case fs.FileTypeTSV:
 		return &tsvParser{}, nil
+	case fs.FileTypeJSON:
+		return &jsonParser{}, nil
+	case fs.FileTypeJSONL:
+		return &jsonlParser{}, nil
+	case fs.FileTypeYAML, fs.FileTypeYML:
+		return &yamlParser{}, nil
+	case fs.FileTypeMD:
+		return &markdownParser{}, nil
 	default:
 		return nil, fmt.Errorf("unsupported parser type")
 	}
@@ -0,0 +1,58 @@
+package table
+
+import (
+	"github.com/tys-muta/go-sqx/cmd/sqlite/config"
+	"github.com/tys-muta/go-sqx/cmd/sqlite/types"
+)
+
+// JSON や YAML などの構造化データの 1 レコード
+type record struct {
+	keys   []string
+	values map[string]string
+}
+
+// 構造化データのレコードを表ファイルと同じ行構成に変換する
+//
+// カラム名はレコードのキーの出現順で決定し、カラム名の行とカラムの型の行 ( 空文字 ) を
+// 設定ファイルで指定された行に、レコードを取り込み開始行以降に配置する
+func recordsToRows(records []record) types.Rows {
+	columns := []string{}
+	columnMap := map[string]bool{}
+	for _, r := range records {
+		for _, key := range r.keys {
+			if columnMap[key] {
+				continue
+			}
+			columnMap[key] = true
+			columns = append(columns, key)
+		}
+	}
+
+	head := config.Get().Head
+	headLength := config.Get().Body.StartRow - 1
+	if headLength < head.ColumnNameRow {
+		headLength = head.ColumnNameRow
+	}
+	if headLength < head.ColumnTypeRow {
+		headLength = head.ColumnTypeRow
+	}
+
+	rows := types.Rows{}
+	for i := 1; i <= headLength; i++ {
+		row := make([]string, len(columns))
+		if i == head.ColumnNameRow {
+			copy(row, columns)
+		}
+		rows = append(rows, row)
+	}
+
+	for _, r := range records {
+		row := make([]string, len(columns))
+		for i, column := range columns {
+			row[i] = r.values[column]
+		}
+		rows = append(rows, row)
+	}
+
+	return rows
+}
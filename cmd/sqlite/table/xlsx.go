@@ -5,6 +5,7 @@ package table
 import (
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	xls "github.com/tealeg/xlsx/v3"
@@ -14,6 +15,17 @@ import (
 
 type xlsxParser struct{}
 
+// 結合セルの扱い
+const (
+	mergedCellFill  = "fill"  // 結合範囲の全セルに左上のセルの値を展開する
+	mergedCellError = "error" // データ行に結合セルが含まれる場合はエラーとする
+)
+
+type cellCoordinates struct {
+	row  int
+	cell int
+}
+
 var _ parser = (*xlsxParser)(nil)
 
 func (p *xlsxParser) Parse(bytes []byte) (types.Rows, error) {
@@ -30,14 +42,23 @@ func (p *xlsxParser) Parse(bytes []byte) (types.Rows, error) {
 			continue
 		}
 
+		mergedMap, err := p.mergedCells(v, file.Date1904)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve merged cells: %w", err)
+		}
+
 		if err := v.ForEachRow(func(row *xls.Row) error {
 			values := []string{}
 			if err := row.ForEachCell(func(cell *xls.Cell) error {
-				cellValue, err := p.parseCell(cell)
+				cellValue, err := p.parseCell(cell, file.Date1904)
 				if err != nil {
 					return fmt.Errorf("failed to parse cell: %w", err)
 				}
-				rowIndex, cellIndex := cell.GetCoordinates()
+				// GetCoordinates は ( 列, 行 ) の順で返す
+				cellIndex, rowIndex := cell.GetCoordinates()
+				if v, ok := mergedMap[cellCoordinates{row: rowIndex, cell: cellIndex}]; ok {
+					cellValue = v
+				}
 				if cellValue == "" && rowIndex == config.Get().Head.ColumnNameRow-1 {
 					invalidMap[cellIndex] = true
 				}
@@ -61,15 +82,69 @@ func (p *xlsxParser) Parse(bytes []byte) (types.Rows, error) {
 	return rows, nil
 }
 
+// 結合セルに覆われているセルの座標と、展開する値の対応を返す
+func (p *xlsxParser) mergedCells(sheet *xls.Sheet, date1904 bool) (map[cellCoordinates]string, error) {
+	mergedMap := map[cellCoordinates]string{}
+
+	mode := config.Get().XLSX.MergedCell
+	if mode != mergedCellFill && mode != mergedCellError {
+		return mergedMap, nil
+	}
+
+	if err := sheet.ForEachRow(func(row *xls.Row) error {
+		return row.ForEachCell(func(cell *xls.Cell) error {
+			if cell.HMerge == 0 && cell.VMerge == 0 {
+				return nil
+			}
+
+			cellIndex, rowIndex := cell.GetCoordinates()
+			if mode == mergedCellError && rowIndex+cell.VMerge >= config.Get().Body.StartRow-1 {
+				return fmt.Errorf("merged cell is not allowed in data rows. row: %d, column: %d", rowIndex+1, cellIndex+1)
+			}
+			if mode != mergedCellFill {
+				return nil
+			}
+
+			cellValue, err := p.parseCell(cell, date1904)
+			if err != nil {
+				return fmt.Errorf("failed to parse cell: %w", err)
+			}
+
+			for i := rowIndex; i <= rowIndex+cell.VMerge; i++ {
+				for j := cellIndex; j <= cellIndex+cell.HMerge; j++ {
+					mergedMap[cellCoordinates{row: i, cell: j}] = cellValue
+				}
+			}
+
+			return nil
+		})
+	}); err != nil {
+		return nil, fmt.Errorf("failed to iterate rows: %w", err)
+	}
+
+	return mergedMap, nil
+}
+
 // セルフォーマットが時間でかつ, 値が数値に場合は RFC3339 形式の文字列に変換する
-func (p *xlsxParser) parseCell(cell *xls.Cell) (string, error) {
+// 時間以外の数値はエクセル上の見た目に合わせて正規化する
+//
+// date1904 はブックが 1904 年起点の日付システム ( Mac 版エクセルなど ) を採用しているかどうか
+func (p *xlsxParser) parseCell(cell *xls.Cell, date1904 bool) (string, error) {
 	float, err := strconv.ParseFloat(cell.Value, 64)
-	if err != nil || !cell.IsTime() || float == 0 {
-		// セルフォーマットが時間でない場合はそのまま返す
+	if err != nil || cell.Type() != xls.CellTypeNumeric && !cell.IsTime() {
+		// 数値でない場合はそのまま返す
+		return cell.Value, nil
+	}
+
+	if !cell.IsTime() {
+		return normalizeNumber(float, strings.Contains(cell.GetNumberFormat(), "%")), nil
+	}
+
+	if float == 0 {
 		return cell.Value, nil
 	}
 
-	t, err := cell.GetTime(false)
+	t, err := cell.GetTime(date1904)
 	if err != nil {
 		return "", fmt.Errorf("failed to get time: %w", err)
 	}
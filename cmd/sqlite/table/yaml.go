@@ -0,0 +1,82 @@
+package table
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/tys-muta/go-sqx/cmd/sqlite/types"
+	"gopkg.in/yaml.v3"
+)
+
+// マッピングのシーケンスで構成される YAML ファイルのパーサー
+type yamlParser struct{}
+
+var _ parser = (*yamlParser)(nil)
+
+func (p *yamlParser) Parse(bytes []byte) (types.Rows, error) {
+	document := yaml.Node{}
+	if err := yaml.Unmarshal(bytes, &document); err != nil {
+		return nil, fmt.Errorf("failed to read yaml file: %w", err)
+	}
+
+	records := []record{}
+	if len(document.Content) == 0 {
+		return recordsToRows(records), nil
+	}
+
+	sequence := document.Content[0]
+	if sequence.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("yaml root must be a sequence. line: %d", sequence.Line)
+	}
+
+	for _, mapping := range sequence.Content {
+		if mapping.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("yaml record must be a mapping. line: %d", mapping.Line)
+		}
+
+		r := record{values: map[string]string{}}
+		for i := 0; i+1 < len(mapping.Content); i += 2 {
+			key := mapping.Content[i].Value
+			value, err := yamlValue(mapping.Content[i+1])
+			if err != nil {
+				return nil, fmt.Errorf("failed to read value of %s. line: %d: %w", key, mapping.Content[i+1].Line, err)
+			}
+
+			if _, ok := r.values[key]; !ok {
+				r.keys = append(r.keys, key)
+			}
+			r.values[key] = value
+		}
+		records = append(records, r)
+	}
+
+	return recordsToRows(records), nil
+}
+
+// YAML の値を表ファイルのセルと同じ文字列に変換する
+//
+// null は空文字、スカラーはそのまま、それ以外 ( マッピング、シーケンス ) は JSON 表現とする
+func yamlValue(node *yaml.Node) (string, error) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	if node.Kind == yaml.ScalarNode {
+		if node.Tag == "!!null" {
+			return "", nil
+		}
+		return node.Value, nil
+	}
+
+	var v any
+	if err := node.Decode(&v); err != nil {
+		return "", err
+	}
+
+	bytes, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return string(bytes), nil
+}
@@ -4,6 +4,12 @@ type Column struct {
 	Type  columnType
 	Name  string
 	Value string
+
+	Nullable bool
+	Default  string
+	Comment  string
+	// 列挙型の分割キーが取りうる値
+	Enum []string
 }
 
 type columnType string
@@ -16,6 +22,16 @@ const (
 	ColumnTypeNumeric    = columnType("NUMERIC")
 )
 
+// 設定ファイルで宣言できる型の名前か ( 空の場合は string として扱う )
+func IsColumnType(v string) bool {
+	switch v {
+	case "", "string", "time", "int", "float", "null_string":
+		return true
+	default:
+		return false
+	}
+}
+
 func ColumnType(v string) columnType {
 	switch v {
 	case "time":
@@ -31,17 +47,37 @@ func ColumnType(v string) columnType {
 	}
 }
 
-func (c columnType) AsSQL() string {
+// 設定ファイルや表ファイルで記述する型の名前
+func (c columnType) Name() string {
 	switch c {
 	case ColumnTypeDateTime:
-		return "`INTEGER(TIMESTAMP)` NOT NULL"
+		return "time"
 	case ColumnTypeInteger:
-		return "INTEGER NOT NULL"
+		return "int"
 	case ColumnTypeNumeric:
-		return "NUMERIC NOT NULL"
+		return "float"
 	case ColumnTypeNullString:
-		return "TEXT NULL"
+		return "null_string"
+	default:
+		return "string"
+	}
+}
+
+// SQLite のカラムの型 ( NULL 制約を含まない )
+func (c columnType) SQLType() string {
+	switch c {
+	case ColumnTypeDateTime:
+		return "INTEGER(TIMESTAMP)"
+	case ColumnTypeInteger:
+		return "INTEGER"
+	case ColumnTypeNumeric:
+		return "NUMERIC"
 	default:
-		return "TEXT NOT NULL"
+		return "TEXT"
 	}
 }
+
+// NULL を許容するカラムか ( 型が null_string の場合、または宣言で nullable を指定した場合 )
+func (c Column) IsNullable() bool {
+	return c.Nullable || c.Type == ColumnTypeNullString
+}
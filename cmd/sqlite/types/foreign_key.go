@@ -1,6 +1,22 @@
 package types
 
+// 外部キー制約
+//
+// 設定ファイルでは columns, table, references で記述する ( column, reference は旧形式 )
+// table は参照先の表ファイルの索引 ( 分割キーを除く ) またはテーブル名、columns, references は表ファイルのカラム名で記述し、
+// 生成時に命名規則を適用したテーブル名とカラム名に解決する
 type ForeignKey struct {
+	// 旧形式 ( e.g. column = "standardId", reference = "standard(id)" )
 	Column    string
 	Reference string
+
+	Columns []string
+	Table   string
+	// 省略時は参照先のプライマリーキー
+	References []string
+	// CASCADE, SET NULL, SET DEFAULT, RESTRICT, NO ACTION
+	OnDelete string
+	OnUpdate string
+	// トランザクションの終了時まで制約の検証を遅らせる
+	Deferrable bool
 }
@@ -4,11 +4,29 @@ type Table struct {
 	Rows
 
 	Index string
-	Name  string
+	// 索引から分割キーの値を取り除いたもの ( 同じテーブル名となる表ファイルが同じテーブルかを判定する )
+	ID   string
+	Name string
+
+	// 読み込んだ表ファイルのパスと内容の SHA-256 ハッシュ
+	Path string
+	Hash string
 
 	PrimaryKey   []string
 	UniqueKeys   [][]string
 	IndexKeys    [][]string
 	ForeignKeys  []ForeignKey
 	ShardColumns []Column
+	ColumnTypes  map[string]string
+	// 分割されたテーブルのカラムを順番ではなくカラム名で対応付ける
+	MapColumnsByName bool
+	// 宣言されたカラム
+	Columns []Column
+	Header  string
+	Schema  string
+	// テーブルの説明
+	Description string
+	// 一致した設定キーと、外部キー制約を推論しないカラム
+	Key               string
+	IgnoreForeignKeys []string
 }
@@ -5,9 +5,15 @@ type FileMap map[string]File
 type FileType string
 
 const (
-	FileTypeXLSX = FileType("xlsx")
-	FileTypeCSV  = FileType("csv")
-	FileTypeTSV  = FileType("tsv")
+	FileTypeXLSX  = FileType("xlsx")
+	FileTypeCSV   = FileType("csv")
+	FileTypeTSV   = FileType("tsv")
+	FileTypeJSON  = FileType("json")
+	FileTypeJSONL = FileType("jsonl")
+	FileTypeYAML  = FileType("yaml")
+	FileTypeYML   = FileType("yml")
+	FileTypeODS   = FileType("ods")
+	FileTypeMD    = FileType("md")
 )
 
 type File struct {
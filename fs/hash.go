@@ -0,0 +1,26 @@
+package fs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/go-git/go-billy/v5"
+)
+
+// ファイルの内容の SHA-256 ハッシュを返す
+func Hash(bfs billy.Basic, file File) (string, error) {
+	f, err := bfs.Open(file.Path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
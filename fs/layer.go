@@ -0,0 +1,114 @@
+package fs
+
+import (
+	"errors"
+	"os"
+	"path"
+	"sort"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/helper/polyfill"
+)
+
+// 複数のファイルシステムを重ね合わせた読み取り専用のファイルシステム
+//
+// 同じパスのファイルが複数のレイヤーに存在する場合は、後に指定したレイヤーのファイルを優先し、
+// ディレクトリの内容は全てのレイヤーの内容を合わせたものとなる
+type layerFS struct {
+	layers []billy.Filesystem
+}
+
+var _ billy.Basic = (*layerFS)(nil)
+var _ billy.Dir = (*layerFS)(nil)
+
+func NewLayer(layers ...billy.Filesystem) billy.Filesystem {
+	return polyfill.New(&layerFS{layers: layers})
+}
+
+func (l *layerFS) Open(filename string) (billy.File, error) {
+	return l.OpenFile(filename, os.O_RDONLY, 0)
+}
+
+func (l *layerFS) OpenFile(filename string, flag int, perm os.FileMode) (billy.File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_APPEND|os.O_TRUNC) != 0 {
+		return nil, billy.ErrReadOnly
+	}
+
+	for i := len(l.layers) - 1; i >= 0; i-- {
+		file, err := l.layers[i].OpenFile(filename, flag, perm)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		return file, err
+	}
+
+	return nil, &os.PathError{Op: "open", Path: filename, Err: os.ErrNotExist}
+}
+
+func (l *layerFS) Stat(filename string) (os.FileInfo, error) {
+	for i := len(l.layers) - 1; i >= 0; i-- {
+		info, err := l.layers[i].Stat(filename)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		return info, err
+	}
+
+	return nil, &os.PathError{Op: "stat", Path: filename, Err: os.ErrNotExist}
+}
+
+func (l *layerFS) ReadDir(dirname string) ([]os.FileInfo, error) {
+	found := false
+	infoMap := map[string]os.FileInfo{}
+	for i := len(l.layers) - 1; i >= 0; i-- {
+		infoList, err := l.layers[i].ReadDir(dirname)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		found = true
+
+		for _, info := range infoList {
+			if _, ok := infoMap[info.Name()]; !ok {
+				infoMap[info.Name()] = info
+			}
+		}
+	}
+	if !found {
+		return nil, &os.PathError{Op: "readdir", Path: dirname, Err: os.ErrNotExist}
+	}
+
+	infoList := []os.FileInfo{}
+	for _, info := range infoMap {
+		infoList = append(infoList, info)
+	}
+	sort.Slice(infoList, func(i, j int) bool { return infoList[i].Name() < infoList[j].Name() })
+
+	return infoList, nil
+}
+
+func (l *layerFS) Join(elem ...string) string {
+	return path.Join(elem...)
+}
+
+func (l *layerFS) Create(filename string) (billy.File, error) {
+	return nil, billy.ErrReadOnly
+}
+
+func (l *layerFS) Rename(oldpath, newpath string) error {
+	return billy.ErrReadOnly
+}
+
+func (l *layerFS) Remove(filename string) error {
+	return billy.ErrReadOnly
+}
+
+func (l *layerFS) MkdirAll(filename string, perm os.FileMode) error {
+	return billy.ErrReadOnly
+}
+
+func (l *layerFS) Capabilities() billy.Capability {
+	return billy.ReadCapability | billy.SeekCapability
+}
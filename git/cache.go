@@ -0,0 +1,142 @@
+package git
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/tys-muta/go-sqx/git/option"
+)
+
+// リモートのデフォルトブランチを保存する参照名
+const remoteHead = plumbing.ReferenceName("refs/remotes/" + git.DefaultRemoteName + "/HEAD")
+
+// リポジトリをキャッシュディレクトリにベアリポジトリとして保持し、
+// 2 回目以降は差分のみをフェッチしてから、指定した参照のファイルを参照するファイルシステムを返す
+//
+// キャッシュディレクトリが空の場合はユーザーのキャッシュディレクトリ配下にリポジトリ毎のディレクトリを作成する
+func CloneCache(url string, dir string, options ...option.CloneOption) (*Snapshot, error) {
+	if url == "" {
+		return nil, fmt.Errorf("git repository reference is required")
+	}
+
+	if dir == "" {
+		v, err := DefaultCacheDir(url)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get default cache dir: %w", err)
+		}
+		dir = v
+	}
+
+	repo, err := openCache(url, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cache [%s]: %w", dir, err)
+	}
+
+	// 認証情報や深さなどはクローンと同じオプションをフェッチにも適用する
+	cloneOptions := git.CloneOptions{URL: url}
+	for _, o := range options {
+		o(&cloneOptions)
+	}
+	checkoutOptions := git.CheckoutOptions{}
+	for _, o := range options {
+		o(&checkoutOptions)
+	}
+	refs := string(checkoutOptions.Branch)
+
+	fetchOptions := git.FetchOptions{
+		RemoteName: git.DefaultRemoteName,
+		Auth:       cloneOptions.Auth,
+		Depth:      cloneOptions.Depth,
+		Progress:   os.Stdout,
+		Force:      true,
+		Tags:       git.AllTags,
+		RefSpecs: []config.RefSpec{
+			"+refs/heads/*:refs/heads/*",
+			config.RefSpec("+HEAD:" + remoteHead),
+		},
+	}
+	if cloneOptions.SingleBranch {
+		// 単一ブランチの場合は対象の参照のみをフェッチする
+		// 参照名に解決できないリビジョン ( コミットのハッシュなど ) の場合は全てのブランチをフェッチする
+		name, err := remoteReference(url, cloneOptions.Auth, refs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve reference: %w", err)
+		}
+		switch {
+		case refs == "":
+			fetchOptions.Tags = git.NoTags
+			fetchOptions.RefSpecs = []config.RefSpec{config.RefSpec("+HEAD:" + remoteHead)}
+		case name != "":
+			fetchOptions.Tags = git.NoTags
+			fetchOptions.RefSpecs = []config.RefSpec{config.RefSpec(fmt.Sprintf("+%s:%s", name, name))}
+		}
+	}
+
+	if err := repo.Fetch(&fetchOptions); err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return nil, fmt.Errorf("failed to fetch: %w", err)
+	}
+
+	// PlainInit で作成した HEAD ( refs/heads/master ) ではなく、リモートのデフォルトブランチを HEAD~n などの基準とする
+	if _, err := repo.Reference(remoteHead, false); err == nil {
+		if err := repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, remoteHead)); err != nil {
+			return nil, fmt.Errorf("failed to set HEAD: %w", err)
+		}
+	}
+
+	if refs == "" {
+		refs = string(remoteHead)
+	}
+
+	snapshot, err := openTree(repo, refs, options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open tree: %w", err)
+	}
+
+	return snapshot, nil
+}
+
+// リポジトリの URL に対応するデフォルトのキャッシュディレクトリを返す
+func DefaultCacheDir(url string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "sqx", "repos", fmt.Sprintf("%x", sha256.Sum256([]byte(url)))), nil
+}
+
+// キャッシュディレクトリのベアリポジトリを開き、存在しない場合は初期化する
+func openCache(url string, dir string) (*git.Repository, error) {
+	repo, err := git.PlainOpen(dir)
+	if errors.Is(err, git.ErrRepositoryNotExists) {
+		if repo, err = git.PlainInit(dir, true); err != nil {
+			return nil, fmt.Errorf("failed to init: %w", err)
+		}
+		if _, err := repo.CreateRemote(&config.RemoteConfig{
+			Name: git.DefaultRemoteName,
+			URLs: []string{url},
+		}); err != nil {
+			return nil, fmt.Errorf("failed to create remote: %w", err)
+		}
+		return repo, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open: %w", err)
+	}
+
+	remote, err := repo.Remote(git.DefaultRemoteName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get remote: %w", err)
+	}
+	if urls := remote.Config().URLs; len(urls) == 0 || urls[0] != url {
+		return nil, fmt.Errorf("cache is used by another repository: %v", urls)
+	}
+
+	return repo, nil
+}
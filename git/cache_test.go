@@ -0,0 +1,60 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/tys-muta/go-sqx/git/option"
+)
+
+// testdata のリポジトリのデフォルトブランチは main で、master は main より 1 コミット進んだ別のブランチ
+func TestCloneCacheRelativeRevision(t *testing.T) {
+	s := newTestServer(t, readSigner(t, "testdata/id_ed25519", "").PublicKey())
+	url := s.url("")
+
+	auth, err := NewSSHAuth(url, SSHConfig{PrivateKeyFile: "testdata/id_ed25519", InsecureIgnoreHostKey: true})
+	if err != nil {
+		t.Fatalf("failed to setup ssh auth: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		refs         string
+		singleBranch bool
+		want         string
+	}{
+		{name: "default", want: "id\tname\nint\tstring\n1\ta\n2\tb\n"},
+		{name: "head", refs: "HEAD", want: "id\tname\nint\tstring\n1\ta\n2\tb\n"},
+		{name: "relative", refs: "HEAD~1", want: "id\tname\nint\tstring\n1\ta\n"},
+		{name: "single branch", refs: "HEAD~1", singleBranch: true, want: "id\tname\nint\tstring\n1\ta\n"},
+		{name: "branch", refs: "master", want: "id\tname\nint\tstring\n1\ta\n2\tb\n3\tc\n"},
+	}
+
+	dir := t.TempDir()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := []option.CloneOption{option.WithAuth(auth)}
+			if tt.refs != "" {
+				options = append(options, option.WithReference(tt.refs))
+			}
+			if tt.singleBranch {
+				options = append(options, option.WithSingleBranch())
+			}
+
+			// 2 回目以降はキャッシュのリポジトリにフェッチする
+			snapshot, err := CloneCache(url, dir, options...)
+			if err != nil {
+				t.Fatalf("failed to clone: %v", err)
+			}
+			defer snapshot.Close()
+
+			bytes, err := util.ReadFile(snapshot, "tables/item.tsv")
+			if err != nil {
+				t.Fatalf("failed to read: %v", err)
+			}
+			if string(bytes) != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, bytes)
+			}
+		})
+	}
+}
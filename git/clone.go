@@ -4,46 +4,66 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/go-git/go-billy/v5"
-	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/osfs"
 	"github.com/go-git/go-git/v5"
-	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/go-git/go-git/v5/plumbing/cache"
+	"github.com/go-git/go-git/v5/storage/filesystem"
 	"github.com/tys-muta/go-sqx/git/option"
 )
 
-func Clone(url string, options ...option.CloneOption) (billy.Filesystem, error) {
+// リポジトリを一時ディレクトリにクローンし、指定した参照のファイルを参照するファイルシステムを返す
+//
+// ワークツリーは展開せず、ファイルシステムからの参照時にオブジェクトストアから直接読み込む
+//
+// go-git は部分クローン ( パスによるオブジェクトの絞り込み ) に対応していないため、パックはディスクに保持し、
+// 参照したオブジェクトのみをメモリに読み込む ( メモリの使用量はリポジトリの大きさに依らない )
+// 一時ディレクトリは Snapshot.Close で削除する
+func Clone(url string, options ...option.CloneOption) (*Snapshot, error) {
 	if url == "" {
 		return nil, fmt.Errorf("git repository reference is required")
 	}
 
-	fs := memfs.New()
-
 	// リポジトリをクローンする
 	cloneOptions := git.CloneOptions{URL: url, Progress: os.Stdout}
 	for _, o := range options {
 		o(&cloneOptions)
 	}
+	cloneOptions.NoCheckout = true
 
-	repo, err := git.Clone(memory.NewStorage(), fs, &cloneOptions)
-	if err != nil {
-		return nil, fmt.Errorf("failed to clone: %w", err)
-	}
-
-	// ブランチをチェックアウトする
+	// 単一ブランチの場合はチェックアウト対象の参照のみをクローンする
+	// 参照名に解決できないリビジョン ( コミットのハッシュなど ) の場合は全てのブランチをクローンする
 	checkoutOptions := git.CheckoutOptions{}
 	for _, o := range options {
 		o(&checkoutOptions)
 	}
-	if branch := checkoutOptions.Branch; branch != "" {
-		workTree, err := repo.Worktree()
+	if cloneOptions.SingleBranch && checkoutOptions.Branch != "" {
+		name, err := remoteReference(url, cloneOptions.Auth, string(checkoutOptions.Branch))
 		if err != nil {
-			return nil, fmt.Errorf("failed to work tree: %w", err)
+			return nil, fmt.Errorf("failed to resolve reference: %w", err)
 		}
+		cloneOptions.ReferenceName = name
+		cloneOptions.SingleBranch = name != ""
+	}
 
-		if err := workTree.Checkout(&checkoutOptions); err != nil {
-			return nil, fmt.Errorf("failed to checkout: %w", err)
-		}
+	dir, err := os.MkdirTemp("", "sqx-clone-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary dir: %w", err)
+	}
+
+	storage := filesystem.NewStorage(osfs.New(dir), cache.NewObjectLRUDefault())
+	repo, err := git.Clone(storage, nil, &cloneOptions)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to clone: %w", err)
+	}
+
+	// 参照先のツリーを開く
+	snapshot, err := openTree(repo, string(checkoutOptions.Branch), options...)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to open tree: %w", err)
 	}
+	snapshot.dir = dir
 
-	return fs, nil
+	return snapshot, nil
 }
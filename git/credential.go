@@ -0,0 +1,285 @@
+package git
+
+import (
+	"bufio"
+	b "bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/mitchellh/go-homedir"
+)
+
+// HTTP のリポジトリの認証情報
+type Credential struct {
+	Username string
+	Password string
+}
+
+// リポジトリの URL に対応する認証情報を返す ( 見つからない場合は nil を返す )
+type CredentialProvider func(url string) (*Credential, error)
+
+// トークンのみが与えられた場合のユーザー名 ( GitHub などはトークンが正しければユーザー名を問わない )
+const defaultCredentialUsername = "git"
+
+// HTTP のリポジトリかどうか
+func IsHTTP(url string) bool {
+	endpoint, err := transport.NewEndpoint(url)
+	if err != nil {
+		return false
+	}
+	return endpoint.Protocol == "http" || endpoint.Protocol == "https"
+}
+
+// URL に含まれる認証情報を除去する ( HTTP の場合はユーザー名も含めて除去し、それ以外はパスワードのみ除去する )
+func StripCredential(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.User == nil {
+		// git@github.com:foo/bar.git のような scp 形式はパスワードを含まないためそのまま返す
+		return rawURL
+	}
+
+	if u.Scheme == "http" || u.Scheme == "https" {
+		u.User = nil
+	} else {
+		u.User = url.User(u.User.Username())
+	}
+
+	return u.String()
+}
+
+// 認証情報を順番に探し、最初に見つかったものを返す
+func ResolveCredential(url string, providers ...CredentialProvider) (*Credential, error) {
+	for _, provider := range providers {
+		credential, err := provider(url)
+		if err != nil {
+			return nil, err
+		}
+		if credential != nil {
+			return credential, nil
+		}
+	}
+	return nil, nil
+}
+
+// 設定ファイルなどで与えられた固定の認証情報
+func StaticCredential(username string, password string) CredentialProvider {
+	return func(url string) (*Credential, error) {
+		if username == "" && password == "" {
+			return nil, nil
+		}
+		if username == "" {
+			username = defaultCredentialUsername
+		}
+		return &Credential{Username: username, Password: password}, nil
+	}
+}
+
+// 環境変数の認証情報
+func EnvCredential(usernameEnv string, passwordEnv string) CredentialProvider {
+	return func(url string) (*Credential, error) {
+		password := os.Getenv(passwordEnv)
+		if password == "" {
+			return nil, nil
+		}
+		return StaticCredential(os.Getenv(usernameEnv), password)(url)
+	}
+}
+
+// トークンを記述したファイルの認証情報 ( ファイルの内容の前後の空白は除去する )
+func FileCredential(path string, username string) CredentialProvider {
+	return func(url string) (*Credential, error) {
+		if path == "" {
+			return nil, nil
+		}
+
+		file, err := homedir.Expand(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to expand path: %w", err)
+		}
+
+		bytes, err := ioutil.ReadFile(file)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read token file: %w", err)
+		}
+
+		return StaticCredential(username, strings.TrimSpace(string(bytes)))(url)
+	}
+}
+
+// .netrc の認証情報 ( パスが空の場合は NETRC 環境変数または ~/.netrc )
+func NetrcCredential(path string) CredentialProvider {
+	return func(url string) (*Credential, error) {
+		if path == "" {
+			path = os.Getenv("NETRC")
+		}
+		if path == "" {
+			path = filepath.Join("~", ".netrc")
+		}
+
+		file, err := homedir.Expand(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to expand path: %w", err)
+		}
+
+		bytes, err := ioutil.ReadFile(file)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read netrc: %w", err)
+		}
+
+		endpoint, err := transport.NewEndpoint(url)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse url: %w", err)
+		}
+
+		return parseNetrc(bytes, endpoint.Host), nil
+	}
+}
+
+// git credential fill で credential helper に問い合わせた認証情報
+func HelperCredential() CredentialProvider {
+	return func(url string) (*Credential, error) {
+		endpoint, err := transport.NewEndpoint(url)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse url: %w", err)
+		}
+
+		host := endpoint.Host
+		if endpoint.Port != 0 {
+			host = fmt.Sprintf("%s:%d", endpoint.Host, endpoint.Port)
+		}
+
+		input := fmt.Sprintf("protocol=%s\nhost=%s\npath=%s\n\n", endpoint.Protocol, host, strings.TrimPrefix(endpoint.Path, "/"))
+
+		// 認証情報が無い場合にプロンプトで入力を求めないようにする
+		cmd := exec.Command("git", "credential", "fill")
+		cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_ASKPASS=", "SSH_ASKPASS=")
+		cmd.Stdin = strings.NewReader(input)
+		output, err := cmd.Output()
+		if err != nil {
+			// git が存在しない、または認証情報が見つからない場合
+			return nil, nil
+		}
+
+		credential := Credential{}
+		scanner := bufio.NewScanner(b.NewReader(output))
+		for scanner.Scan() {
+			key, value, ok := strings.Cut(scanner.Text(), "=")
+			if !ok {
+				continue
+			}
+			switch key {
+			case "username":
+				credential.Username = value
+			case "password":
+				credential.Password = value
+			}
+		}
+		if credential.Password == "" {
+			return nil, nil
+		}
+
+		return StaticCredential(credential.Username, credential.Password)(url)
+	}
+}
+
+// .netrc をトークンに分割する
+//
+// macdef のマクロ定義は次の空行で終わるため、マクロ名の後から空行までを取り除く
+func netrcTokens(text string) []string {
+	tokens := []string{}
+	inMacro := false
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if inMacro {
+			if strings.TrimSpace(line) == "" {
+				inMacro = false
+			}
+			continue
+		}
+		fields := strings.Fields(line)
+		for i, field := range fields {
+			tokens = append(tokens, field)
+			if field == "macdef" {
+				// マクロ名を残し、同じ行の以降と続く行を取り除く
+				if i+1 < len(fields) {
+					tokens = append(tokens, fields[i+1])
+				}
+				inMacro = true
+				break
+			}
+		}
+	}
+	return tokens
+}
+
+// .netrc からホストに対応する認証情報を取得する ( 一致するホストが無い場合は default を利用する )
+func parseNetrc(bytes []byte, host string) *Credential {
+	var found *Credential
+	var fallback *Credential
+	var current *Credential
+
+	tokens := netrcTokens(string(bytes))
+	for i := 0; i < len(tokens); i++ {
+		switch tokens[i] {
+		case "machine":
+			current = nil
+			if i+1 < len(tokens) {
+				i++
+				if tokens[i] == host && found == nil {
+					found = &Credential{}
+					current = found
+				}
+			}
+		case "default":
+			current = nil
+			if fallback == nil {
+				fallback = &Credential{}
+				current = fallback
+			}
+		case "login":
+			if i+1 < len(tokens) {
+				i++
+				if current != nil {
+					current.Username = tokens[i]
+				}
+			}
+		case "password":
+			if i+1 < len(tokens) {
+				i++
+				if current != nil {
+					current.Password = tokens[i]
+				}
+			}
+		case "macdef":
+			// マクロ定義の本文は netrcTokens で取り除かれている
+			current = nil
+			if i+1 < len(tokens) {
+				i++
+			}
+		}
+	}
+
+	if found == nil {
+		found = fallback
+	}
+	if found == nil || found.Password == "" {
+		return nil
+	}
+	if found.Username == "" {
+		found.Username = defaultCredentialUsername
+	}
+
+	return found
+}
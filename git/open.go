@@ -0,0 +1,56 @@
+package git
+
+import (
+	"fmt"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/tys-muta/go-sqx/git/option"
+)
+
+// ローカルの既存リポジトリを開き、指定したリビジョンのファイルを参照するファイルシステムを返す
+//
+// ワークツリーには触れずにオブジェクトストアから直接読み込むため、ベアリポジトリも対象にできる
+func Open(path string, rev string, options ...option.CloneOption) (*Snapshot, error) {
+	if path == "" {
+		return nil, fmt.Errorf("git repository path is required")
+	}
+
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open: %w", err)
+	}
+
+	snapshot, err := openTree(repo, rev, options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open tree: %w", err)
+	}
+
+	return snapshot, nil
+}
+
+// リビジョンを解決し、そのコミットのツリーを参照するファイルシステムを返す
+//
+// リビジョンが空の場合は HEAD を対象とする
+func openTree(repo *git.Repository, rev string, options ...option.CloneOption) (*Snapshot, error) {
+	hash, err := resolveRevision(repo, rev)
+	if err != nil {
+		return nil, err
+	}
+
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit [%s]: %w", hash, err)
+	}
+
+	treeOptions := option.TreeOptions{}
+	for _, o := range options {
+		o(&treeOptions)
+	}
+
+	bfs, err := newTreeFS(commit, treeOptions.Paths)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open tree [%s]: %w", hash, err)
+	}
+
+	return &Snapshot{Filesystem: bfs, Hash: *hash}, nil
+}
@@ -2,14 +2,14 @@ package option
 
 import (
 	"github.com/go-git/go-git/v5"
-	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/go-git/go-git/v5/plumbing/transport"
 )
 
-func WithPublicKeys(key ssh.PublicKeys) CloneOption {
+func WithAuth(auth transport.AuthMethod) CloneOption {
 	return func(options any) {
 		switch o := options.(type) {
 		case *git.CloneOptions:
-			o.Auth = &key
+			o.Auth = auth
 		}
 	}
 }
@@ -0,0 +1,18 @@
+package option
+
+// ツリーを参照するファイルシステムに関するオプション
+type TreeOptions struct {
+	Paths []string
+}
+
+// 参照するパスを限定する ( 指定したパス配下以外のファイルやディレクトリは存在しないものとして扱う )
+//
+// 取得するオブジェクトは限定しないため、クローン時の通信量やディスクの使用量は減らない
+func WithPaths(paths ...string) CloneOption {
+	return func(options any) {
+		switch o := options.(type) {
+		case *TreeOptions:
+			o.Paths = append(o.Paths, paths...)
+		}
+	}
+}
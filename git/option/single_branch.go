@@ -0,0 +1,14 @@
+package option
+
+import (
+	"github.com/go-git/go-git/v5"
+)
+
+func WithSingleBranch() CloneOption {
+	return func(options any) {
+		switch o := options.(type) {
+		case *git.CloneOptions:
+			o.SingleBranch = true
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+// リビジョンを解決したコミットのファイルシステム
+type Snapshot struct {
+	billy.Filesystem
+	// 解決したコミットのハッシュ
+	Hash plumbing.Hash
+	// クローンしたリポジトリを保持する一時ディレクトリ ( Close で削除する )
+	dir string
+}
+
+// 一時ディレクトリにクローンした場合はディレクトリを削除する
+func (s *Snapshot) Close() error {
+	if s.dir == "" {
+		return nil
+	}
+	if err := os.RemoveAll(s.dir); err != nil {
+		return fmt.Errorf("failed to remove temporary dir: %w", err)
+	}
+	s.dir = ""
+	return nil
+}
+
+// リビジョンをコミットのハッシュに解決する
+//
+// ブランチ名 ( main, refs/heads/main )、タグ名 ( v1.0.0, refs/tags/v1.0.0 )、
+// コミットのハッシュ ( 省略形を含む )、HEAD~n などの相対指定に対応し、
+// ローカルに存在しないブランチはリモート追跡ブランチから解決する
+func resolveRevision(repo *git.Repository, rev string) (*plumbing.Hash, error) {
+	if rev == "" {
+		rev = string(plumbing.HEAD)
+	}
+
+	candidates := []string{rev}
+	switch {
+	case strings.HasPrefix(rev, "refs/heads/"):
+		candidates = append(candidates, "refs/remotes/"+git.DefaultRemoteName+"/"+strings.TrimPrefix(rev, "refs/heads/"))
+	case strings.HasPrefix(rev, string(plumbing.HEAD)):
+		candidates = append(candidates, string(remoteHead)+strings.TrimPrefix(rev, string(plumbing.HEAD)))
+	case !strings.HasPrefix(rev, "refs/"):
+		candidates = append(candidates, git.DefaultRemoteName+"/"+rev)
+	}
+
+	var err error
+	for _, v := range candidates {
+		var hash *plumbing.Hash
+		if hash, err = repo.ResolveRevision(plumbing.Revision(v)); err == nil {
+			return hash, nil
+		}
+	}
+
+	return nil, fmt.Errorf("failed to resolve revision [%s]: %w", rev, err)
+}
+
+// リモートリポジトリの参照一覧から、リビジョンに対応する参照名を返す
+//
+// コミットのハッシュや HEAD~n などの参照名ではないリビジョンの場合は空を返す
+func remoteReference(url string, auth transport.AuthMethod, rev string) (plumbing.ReferenceName, error) {
+	if rev == "" {
+		return "", nil
+	}
+
+	remote := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{
+		Name: git.DefaultRemoteName,
+		URLs: []string{url},
+	})
+	refs, err := remote.List(&git.ListOptions{Auth: auth})
+	if err != nil && !errors.Is(err, transport.ErrEmptyRemoteRepository) {
+		return "", fmt.Errorf("failed to list references: %w", err)
+	}
+
+	candidates := []plumbing.ReferenceName{
+		plumbing.ReferenceName(rev),
+		plumbing.NewBranchReferenceName(rev),
+		plumbing.NewTagReferenceName(rev),
+	}
+	for _, candidate := range candidates {
+		for _, ref := range refs {
+			if ref.Name() == candidate {
+				return candidate, nil
+			}
+		}
+	}
+
+	return "", nil
+}
@@ -0,0 +1,179 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strconv"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/mitchellh/go-homedir"
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// SSH 認証の設定
+type SSHConfig struct {
+	// 空の場合はリポジトリの URL ( e.g. git@github.com:foo/bar.git ) から取得し、URL にも含まれない場合は git とする
+	User string
+	// 秘密鍵のファイルパスとパスワード ( RSA, ECDSA, Ed25519 の PEM 形式および OpenSSH 形式に対応 )
+	PrivateKeyFile     string
+	PrivateKeyPassword string
+	// 秘密鍵のファイルパスが空の場合に ssh-agent を利用する
+	Agent bool
+	// ホスト鍵の検証に利用する known_hosts ファイル ( 空の場合は SSH_KNOWN_HOSTS または ~/.ssh/known_hosts )
+	KnownHosts []string
+	// ホスト鍵を検証しない
+	InsecureIgnoreHostKey bool
+}
+
+// SSH のリポジトリかどうか
+func IsSSH(url string) bool {
+	endpoint, err := transport.NewEndpoint(url)
+	if err != nil {
+		return false
+	}
+	return endpoint.Protocol == "ssh"
+}
+
+// SSH の認証方法を生成する
+func NewSSHAuth(url string, cfg SSHConfig) (transport.AuthMethod, error) {
+	endpoint, err := transport.NewEndpoint(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse url: %w", err)
+	}
+
+	user := cfg.User
+	if user == "" {
+		user = endpoint.User
+	}
+	if user == "" {
+		user = "git"
+	}
+
+	var callback gossh.HostKeyCallback
+	var algorithms []string
+	if cfg.InsecureIgnoreHostKey {
+		callback = gossh.InsecureIgnoreHostKey()
+	} else {
+		files := []string{}
+		for _, v := range cfg.KnownHosts {
+			file, err := homedir.Expand(v)
+			if err != nil {
+				return nil, fmt.Errorf("failed to expand path: %w", err)
+			}
+			files = append(files, file)
+		}
+		if callback, err = ssh.NewKnownHostsCallback(files...); err != nil {
+			return nil, fmt.Errorf("failed to load known hosts: %w", err)
+		}
+		port := endpoint.Port
+		if port == 0 {
+			port = 22
+		}
+		algorithms = hostKeyAlgorithms(callback, net.JoinHostPort(endpoint.Host, strconv.Itoa(port)))
+	}
+
+	var auth ssh.AuthMethod
+	switch {
+	case cfg.PrivateKeyFile != "":
+		file, err := homedir.Expand(cfg.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to expand path: %w", err)
+		}
+
+		bytes, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read private key: %w", err)
+		}
+
+		signer, err := gossh.ParsePrivateKey(bytes)
+		var missing *gossh.PassphraseMissingError
+		if errors.As(err, &missing) {
+			if cfg.PrivateKeyPassword == "" {
+				return nil, fmt.Errorf("private key is encrypted but password is empty")
+			}
+			signer, err = gossh.ParsePrivateKeyWithPassphrase(bytes, []byte(cfg.PrivateKeyPassword))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse private key: %w", err)
+		}
+
+		keys := &ssh.PublicKeys{User: user, Signer: signer}
+		keys.HostKeyCallback = callback
+		auth = keys
+	case cfg.Agent:
+		agent, err := ssh.NewSSHAgentAuth(user)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to ssh-agent: %w", err)
+		}
+		agent.HostKeyCallback = callback
+		auth = agent
+	default:
+		return nil, fmt.Errorf("private key or ssh-agent is required")
+	}
+
+	return &sshAuth{AuthMethod: auth, hostKeyAlgorithms: algorithms}, nil
+}
+
+// known_hosts に登録されている鍵の種類に合わせてホスト鍵のアルゴリズムを限定する認証方法
+//
+// 限定しない場合、サーバーが known_hosts とは別の種類のホスト鍵を提示して key mismatch となることがある
+type sshAuth struct {
+	ssh.AuthMethod
+	hostKeyAlgorithms []string
+}
+
+var _ ssh.AuthMethod = (*sshAuth)(nil)
+
+func (a *sshAuth) ClientConfig() (*gossh.ClientConfig, error) {
+	config, err := a.AuthMethod.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(a.hostKeyAlgorithms) > 0 {
+		config.HostKeyAlgorithms = a.hostKeyAlgorithms
+	}
+
+	return config, nil
+}
+
+// known_hosts に登録されているホストの鍵の種類から、利用可能なホスト鍵のアルゴリズムを返す
+func hostKeyAlgorithms(callback gossh.HostKeyCallback, address string) []string {
+	// 存在しない鍵で検証し、エラーに含まれる登録済みの鍵を取得する
+	err := callback(address, &net.TCPAddr{IP: net.IPv4zero}, placeholderKey{})
+
+	var keyErr *knownhosts.KeyError
+	if !errors.As(err, &keyErr) {
+		return nil
+	}
+
+	algorithms := []string{}
+	for _, v := range keyErr.Want {
+		switch v.Key.Type() {
+		case gossh.KeyAlgoRSA:
+			algorithms = append(algorithms, gossh.KeyAlgoRSASHA512, gossh.KeyAlgoRSASHA256, gossh.KeyAlgoRSA)
+		default:
+			algorithms = append(algorithms, v.Key.Type())
+		}
+	}
+
+	return algorithms
+}
+
+type placeholderKey struct{}
+
+func (placeholderKey) Type() string {
+	return "placeholder"
+}
+
+func (placeholderKey) Marshal() []byte {
+	return []byte{}
+}
+
+func (placeholderKey) Verify(data []byte, sig *gossh.Signature) error {
+	return fmt.Errorf("placeholder key cannot verify")
+}
@@ -0,0 +1,401 @@
+package git
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/go-git/go-git/v5/plumbing/protocol/packp"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/server"
+	"github.com/tys-muta/go-sqx/git/option"
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// 公開鍵認証のみを受け付け、testdata のリポジトリを git-upload-pack で提供するテスト用の SSH サーバー
+type testServer struct {
+	addr    string
+	hostKey gossh.Signer
+
+	mu sync.Mutex
+	// 最後に認証に成功したユーザー
+	user string
+}
+
+func newTestServer(t *testing.T, authorized ...gossh.PublicKey) *testServer {
+	t.Helper()
+
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate host key: %v", err)
+	}
+	hostKey, err := gossh.NewSignerFromKey(private)
+	if err != nil {
+		t.Fatalf("failed to create host key signer: %v", err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	s := &testServer{addr: listener.Addr().String(), hostKey: hostKey}
+
+	config := &gossh.ServerConfig{
+		PublicKeyCallback: func(conn gossh.ConnMetadata, key gossh.PublicKey) (*gossh.Permissions, error) {
+			for _, v := range authorized {
+				if string(key.Marshal()) == string(v.Marshal()) {
+					return nil, nil
+				}
+			}
+			return nil, fmt.Errorf("unauthorized key")
+		},
+	}
+	config.AddHostKey(hostKey)
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn, config)
+		}
+	}()
+
+	return s
+}
+
+func (s *testServer) serve(conn net.Conn, config *gossh.ServerConfig) {
+	defer conn.Close()
+
+	serverConn, channels, requests, err := gossh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	s.user = serverConn.User()
+	s.mu.Unlock()
+
+	go gossh.DiscardRequests(requests)
+	for v := range channels {
+		if v.ChannelType() != "session" {
+			v.Reject(gossh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+		channel, requests, err := v.Accept()
+		if err != nil {
+			return
+		}
+		go s.session(channel, requests)
+	}
+}
+
+// exec で要求された git-upload-pack のみを処理する
+func (s *testServer) session(channel gossh.Channel, requests <-chan *gossh.Request) {
+	defer channel.Close()
+
+	for req := range requests {
+		if req.Type != "exec" {
+			req.Reply(false, nil)
+			continue
+		}
+
+		var payload struct{ Command string }
+		if err := gossh.Unmarshal(req.Payload, &payload); err != nil {
+			req.Reply(false, nil)
+			return
+		}
+		name, path, _ := strings.Cut(payload.Command, " ")
+		if name != transport.UploadPackServiceName {
+			req.Reply(false, nil)
+			return
+		}
+		req.Reply(true, nil)
+
+		status := uint32(0)
+		if err := uploadPack(channel, strings.Trim(path, "'")); err != nil {
+			fmt.Fprintln(channel.Stderr(), err)
+			status = 1
+		}
+		channel.SendRequest("exit-status", false, gossh.Marshal(struct{ Status uint32 }{status}))
+		return
+	}
+}
+
+// testdata 配下のリポジトリの参照とパックを送信する
+func uploadPack(channel gossh.Channel, path string) error {
+	endpoint, err := transport.NewEndpoint(path)
+	if err != nil {
+		return err
+	}
+
+	loader := server.NewFilesystemLoader(osfs.New("testdata"))
+	session, err := server.NewServer(loader).NewUploadPackSession(endpoint, nil)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	refs, err := session.AdvertisedReferences()
+	if err != nil {
+		return err
+	}
+	if err := refs.Encode(channel); err != nil {
+		return err
+	}
+
+	req := packp.NewUploadPackRequest()
+	if err := req.Decode(channel); err != nil {
+		return err
+	}
+	res, err := session.UploadPack(context.Background(), req)
+	if err != nil {
+		return err
+	}
+
+	return res.Encode(channel)
+}
+
+func (s *testServer) url(user string) string {
+	if user != "" {
+		user += "@"
+	}
+	return fmt.Sprintf("ssh://%s%s/repo.git", user, s.addr)
+}
+
+// NewSSHAuth で生成した認証方法でリポジトリをクローンし、認証したユーザーを返す
+func (s *testServer) clone(t *testing.T, url string, cfg SSHConfig) (string, error) {
+	t.Helper()
+
+	auth, err := NewSSHAuth(url, cfg)
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	s.user = ""
+	s.mu.Unlock()
+
+	snapshot, err := Clone(url, option.WithAuth(auth))
+	if err != nil {
+		return "", err
+	}
+	defer snapshot.Close()
+
+	bytes, err := util.ReadFile(snapshot, "tables/item.tsv")
+	if err != nil {
+		t.Fatalf("failed to read cloned file: %v", err)
+	}
+	if !strings.HasPrefix(string(bytes), "id\tname\n") {
+		t.Fatalf("unexpected cloned file: %q", bytes)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.user, nil
+}
+
+func readSigner(t *testing.T, file string, password string) gossh.Signer {
+	t.Helper()
+
+	bytes, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("failed to read private key: %v", err)
+	}
+	if password == "" {
+		signer, err := gossh.ParsePrivateKey(bytes)
+		if err != nil {
+			t.Fatalf("failed to parse private key: %v", err)
+		}
+		return signer
+	}
+	signer, err := gossh.ParsePrivateKeyWithPassphrase(bytes, []byte(password))
+	if err != nil {
+		t.Fatalf("failed to parse private key: %v", err)
+	}
+	return signer
+}
+
+func TestNewSSHAuthPrivateKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		password string
+		err      string
+	}{
+		{name: "ed25519", file: "testdata/id_ed25519"},
+		{name: "encrypted", file: "testdata/id_ed25519_encrypted", password: "password"},
+		{name: "encrypted without password", file: "testdata/id_ed25519_encrypted", err: "password is empty"},
+		{name: "encrypted with wrong password", file: "testdata/id_ed25519_encrypted", password: "wrong", err: "failed to parse private key"},
+	}
+
+	s := newTestServer(t,
+		readSigner(t, "testdata/id_ed25519", "").PublicKey(),
+		readSigner(t, "testdata/id_ed25519_encrypted", "password").PublicKey(),
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := SSHConfig{PrivateKeyFile: tt.file, PrivateKeyPassword: tt.password, InsecureIgnoreHostKey: true}
+
+			user, err := s.clone(t, s.url(""), cfg)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected error containing %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to clone: %v", err)
+			}
+			if user != "git" {
+				t.Errorf("expected user git, got %s", user)
+			}
+		})
+	}
+}
+
+func TestNewSSHAuthUser(t *testing.T) {
+	s := newTestServer(t, readSigner(t, "testdata/id_ed25519", "").PublicKey())
+
+	tests := []struct {
+		name string
+		url  string
+		user string
+		want string
+	}{
+		{name: "default", url: s.url(""), want: "git"},
+		{name: "url", url: s.url("alice"), want: "alice"},
+		{name: "config", url: s.url("alice"), user: "bob", want: "bob"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := SSHConfig{User: tt.user, PrivateKeyFile: "testdata/id_ed25519", InsecureIgnoreHostKey: true}
+
+			user, err := s.clone(t, tt.url, cfg)
+			if err != nil {
+				t.Fatalf("failed to clone: %v", err)
+			}
+			if user != tt.want {
+				t.Errorf("expected user %s, got %s", tt.want, user)
+			}
+		})
+	}
+}
+
+func TestNewSSHAuthAgent(t *testing.T) {
+	s := newTestServer(t, readSigner(t, "testdata/id_ed25519", "").PublicKey())
+
+	keyring := agent.NewKeyring()
+	if err := keyring.Add(agent.AddedKey{PrivateKey: readRawKey(t, "testdata/id_ed25519")}); err != nil {
+		t.Fatalf("failed to add key: %v", err)
+	}
+
+	// unix ソケットのパスの長さ制限を避けるため短いディレクトリを使う
+	dir, err := os.MkdirTemp("", "sqx")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	socket := filepath.Join(dir, "agent.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				agent.ServeAgent(keyring, conn)
+			}()
+		}
+	}()
+	t.Setenv("SSH_AUTH_SOCK", socket)
+
+	user, err := s.clone(t, s.url("alice"), SSHConfig{Agent: true, InsecureIgnoreHostKey: true})
+	if err != nil {
+		t.Fatalf("failed to clone: %v", err)
+	}
+	if user != "alice" {
+		t.Errorf("expected user alice, got %s", user)
+	}
+}
+
+func TestNewSSHAuthKnownHosts(t *testing.T) {
+	s := newTestServer(t, readSigner(t, "testdata/id_ed25519", "").PublicKey())
+	other := newTestServer(t, readSigner(t, "testdata/id_ed25519", "").PublicKey())
+
+	// 別のアドレスのホスト鍵のみを登録した known_hosts
+	unknown := s.knownHosts(t, "127.0.0.1:1")
+
+	tests := []struct {
+		name    string
+		cfg     SSHConfig
+		wantErr bool
+	}{
+		{name: "known", cfg: SSHConfig{KnownHosts: []string{s.knownHosts(t, s.addr)}}},
+		{name: "unknown", cfg: SSHConfig{KnownHosts: []string{unknown}}, wantErr: true},
+		{name: "mismatch", cfg: SSHConfig{KnownHosts: []string{other.knownHosts(t, s.addr)}}, wantErr: true},
+		{name: "insecure", cfg: SSHConfig{KnownHosts: []string{unknown}, InsecureIgnoreHostKey: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.PrivateKeyFile = "testdata/id_ed25519"
+
+			_, err := s.clone(t, s.url(""), tt.cfg)
+			if tt.wantErr && err == nil {
+				t.Fatalf("expected host key error")
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("failed to clone: %v", err)
+			}
+		})
+	}
+}
+
+// 指定したアドレスにこのサーバーのホスト鍵を登録した known_hosts を作成する
+func (s *testServer) knownHosts(t *testing.T, addr string) string {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), "known_hosts")
+	line := knownhosts.Line([]string{knownhosts.Normalize(addr)}, s.hostKey.PublicKey())
+	if err := os.WriteFile(file, []byte(line+"\n"), 0600); err != nil {
+		t.Fatalf("failed to write known hosts: %v", err)
+	}
+	return file
+}
+
+func readRawKey(t *testing.T, file string) any {
+	t.Helper()
+
+	bytes, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("failed to read private key: %v", err)
+	}
+	key, err := gossh.ParseRawPrivateKey(bytes)
+	if err != nil {
+		t.Fatalf("failed to parse private key: %v", err)
+	}
+	return key
+}
@@ -0,0 +1,278 @@
+package git
+
+import (
+	b "bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/helper/polyfill"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// コミットのツリーをワークツリーに展開せずにオブジェクトストアから直接読み込む読み取り専用のファイルシステム
+//
+// paths が指定されている場合は、そのパス配下 ( と、そこに至るディレクトリ ) 以外は存在しないものとして扱い、
+// 対象外のツリーやファイルを解決しない
+type treeFS struct {
+	tree    *object.Tree
+	modTime time.Time
+	paths   []string
+}
+
+var _ billy.Basic = (*treeFS)(nil)
+var _ billy.Dir = (*treeFS)(nil)
+
+func newTreeFS(commit *object.Commit, paths []string) (billy.Filesystem, error) {
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tree: %w", err)
+	}
+
+	t := &treeFS{tree: tree, modTime: commit.Committer.When}
+	for _, v := range paths {
+		v = t.clean(v)
+		if v == "" {
+			// ルートが含まれる場合は全てのパスが対象となる
+			t.paths = nil
+			break
+		}
+		t.paths = append(t.paths, v)
+	}
+
+	return polyfill.New(t), nil
+}
+
+func (t *treeFS) Open(filename string) (billy.File, error) {
+	return t.OpenFile(filename, os.O_RDONLY, 0)
+}
+
+func (t *treeFS) OpenFile(filename string, flag int, perm os.FileMode) (billy.File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_APPEND|os.O_TRUNC) != 0 {
+		return nil, billy.ErrReadOnly
+	}
+
+	name := t.clean(filename)
+	if !t.contains(name) {
+		return nil, t.pathError("open", filename, os.ErrNotExist)
+	}
+
+	file, err := t.tree.File(name)
+	if err != nil {
+		return nil, t.pathError("open", filename, err)
+	}
+
+	reader, err := file.Reader()
+	if err != nil {
+		return nil, t.pathError("open", filename, err)
+	}
+	defer reader.Close()
+
+	bytes, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, t.pathError("open", filename, err)
+	}
+
+	return &treeFile{Reader: b.NewReader(bytes), name: filename}, nil
+}
+
+func (t *treeFS) Stat(filename string) (os.FileInfo, error) {
+	name := t.clean(filename)
+	if name == "" {
+		return &treeFileInfo{name: "/", mode: os.ModeDir | 0755, modTime: t.modTime}, nil
+	}
+	if !t.contains(name) && !t.isAncestor(name) {
+		return nil, t.pathError("stat", filename, os.ErrNotExist)
+	}
+
+	entry, err := t.tree.FindEntry(name)
+	if err != nil {
+		return nil, t.pathError("stat", filename, err)
+	}
+
+	return t.fileInfo(name, entry)
+}
+
+func (t *treeFS) ReadDir(dirname string) ([]os.FileInfo, error) {
+	name := t.clean(dirname)
+	if name != "" && !t.contains(name) && !t.isAncestor(name) {
+		return nil, t.pathError("readdir", dirname, os.ErrNotExist)
+	}
+
+	tree := t.tree
+	if name != "" {
+		v, err := t.tree.Tree(name)
+		if err != nil {
+			return nil, t.pathError("readdir", dirname, err)
+		}
+		tree = v
+	}
+
+	infoList := []os.FileInfo{}
+	for _, entry := range tree.Entries {
+		entry := entry
+		entryName := path.Join(name, entry.Name)
+		if !t.contains(entryName) && !t.isAncestor(entryName) {
+			continue
+		}
+		info, err := t.fileInfo(entryName, &entry)
+		if err != nil {
+			return nil, t.pathError("readdir", dirname, err)
+		}
+		infoList = append(infoList, info)
+	}
+
+	return infoList, nil
+}
+
+func (t *treeFS) Join(elem ...string) string {
+	return path.Join(elem...)
+}
+
+func (t *treeFS) Create(filename string) (billy.File, error) {
+	return nil, billy.ErrReadOnly
+}
+
+func (t *treeFS) Rename(oldpath, newpath string) error {
+	return billy.ErrReadOnly
+}
+
+func (t *treeFS) Remove(filename string) error {
+	return billy.ErrReadOnly
+}
+
+func (t *treeFS) MkdirAll(filename string, perm os.FileMode) error {
+	return billy.ErrReadOnly
+}
+
+func (t *treeFS) Capabilities() billy.Capability {
+	return billy.ReadCapability | billy.SeekCapability
+}
+
+func (t *treeFS) fileInfo(name string, entry *object.TreeEntry) (os.FileInfo, error) {
+	info := &treeFileInfo{name: path.Base(name), modTime: t.modTime}
+
+	if entry.Mode == filemode.Dir {
+		info.mode = os.ModeDir | 0755
+		return info, nil
+	}
+
+	mode, err := entry.Mode.ToOSFileMode()
+	if err != nil {
+		return nil, err
+	}
+	info.mode = mode
+
+	size, err := t.tree.Size(name)
+	if err != nil {
+		return nil, err
+	}
+	info.size = size
+
+	return info, nil
+}
+
+// 参照対象のパス配下かどうか
+func (t *treeFS) contains(name string) bool {
+	if len(t.paths) == 0 {
+		return true
+	}
+	for _, v := range t.paths {
+		if name == v || strings.HasPrefix(name, v+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// 参照対象のパスに至るディレクトリかどうか
+func (t *treeFS) isAncestor(name string) bool {
+	for _, v := range t.paths {
+		if name == "" || strings.HasPrefix(v, name+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// パスをツリー内のパス ( 先頭の / や . を含まない ) に変換する
+func (t *treeFS) clean(filename string) string {
+	name := path.Clean("/" + strings.ReplaceAll(filename, "\\", "/"))
+	return strings.TrimPrefix(name, "/")
+}
+
+func (t *treeFS) pathError(op string, filename string, err error) error {
+	if err == object.ErrFileNotFound || err == object.ErrDirectoryNotFound || err == object.ErrEntryNotFound {
+		err = os.ErrNotExist
+	}
+	return &os.PathError{Op: op, Path: filename, Err: err}
+}
+
+type treeFile struct {
+	*b.Reader
+	name string
+}
+
+var _ billy.File = (*treeFile)(nil)
+
+func (f *treeFile) Name() string {
+	return f.name
+}
+
+func (f *treeFile) Write(p []byte) (int, error) {
+	return 0, billy.ErrReadOnly
+}
+
+func (f *treeFile) Close() error {
+	return nil
+}
+
+func (f *treeFile) Lock() error {
+	return nil
+}
+
+func (f *treeFile) Unlock() error {
+	return nil
+}
+
+func (f *treeFile) Truncate(size int64) error {
+	return billy.ErrReadOnly
+}
+
+type treeFileInfo struct {
+	name    string
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+}
+
+var _ os.FileInfo = (*treeFileInfo)(nil)
+
+func (i *treeFileInfo) Name() string {
+	return i.name
+}
+
+func (i *treeFileInfo) Size() int64 {
+	return i.size
+}
+
+func (i *treeFileInfo) Mode() os.FileMode {
+	return i.mode
+}
+
+func (i *treeFileInfo) ModTime() time.Time {
+	return i.modTime
+}
+
+func (i *treeFileInfo) IsDir() bool {
+	return i.mode.IsDir()
+}
+
+func (i *treeFileInfo) Sys() any {
+	return nil
+}